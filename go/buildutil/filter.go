@@ -0,0 +1,86 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buildutil
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// FilterFiles returns the subset of the already parsed files that
+// match the build context ctxt: files whose names or "// +build"
+// constraints (see go/build) exclude them for ctxt are dropped.
+// The order of the remaining files is preserved.
+//
+// The constraints are evaluated by ctxt.MatchFile using the comments
+// preceding the package clause of each file, so the files must have
+// been parsed with parser.ParseComments. The file names are taken
+// from fset. Files whose constraints cannot be evaluated are kept.
+//
+// FilterFiles is useful to avoid conflicting platform-specific
+// declarations when type-checking files that were not selected
+// by go/build.
+//
+func FilterFiles(fset *token.FileSet, ctxt *build.Context, files []*ast.File) []*ast.File {
+	var list []*ast.File
+	for _, f := range files {
+		if ok, err := matchFile(fset, ctxt, f); ok || err != nil {
+			list = append(list, f)
+		}
+	}
+	return list
+}
+
+// matchFile reports whether f matches the build context ctxt.
+func matchFile(fset *token.FileSet, ctxt *build.Context, f *ast.File) (bool, error) {
+	filename := fset.Position(f.Package).Filename
+	header := fileHeader(fset, f)
+
+	// Let go/build read the file header from memory.
+	c := *ctxt
+	c.JoinPath = func(elem ...string) string { return filename }
+	c.OpenFile = func(string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(header)), nil
+	}
+	return c.MatchFile(filepath.Dir(filename), filepath.Base(filename))
+}
+
+// fileHeader reconstructs the source of f up to and including the
+// package clause from its comments. Comments are placed on their
+// original lines since the placement of build constraints matters.
+func fileHeader(fset *token.FileSet, f *ast.File) []byte {
+	var buf bytes.Buffer
+	line := 1
+	moveTo := func(pos token.Pos) {
+		l := fset.Position(pos).Line
+		if l == line && buf.Len() > 0 {
+			buf.WriteByte(' ')
+		}
+		for ; line < l; line++ {
+			buf.WriteByte('\n')
+		}
+	}
+
+	for _, g := range f.Comments {
+		if g.Pos() >= f.Package {
+			break
+		}
+		for _, c := range g.List {
+			moveTo(c.Pos())
+			buf.WriteString(c.Text)
+			line += strings.Count(c.Text, "\n")
+		}
+	}
+	moveTo(f.Package)
+	buf.WriteString("package " + f.Name.Name + "\n")
+
+	return buf.Bytes()
+}
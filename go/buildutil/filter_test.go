@@ -0,0 +1,70 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buildutil_test
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/buildutil"
+)
+
+func TestFilterFiles(t *testing.T) {
+	sources := []struct {
+		filename, src string
+	}{
+		{"all.go", "package p"},
+		{"p_linux.go", "package p"},
+		{"p_windows.go", "package p"},
+		{"p_linux_386.go", "package p"},
+		{"tag1.go", "// +build linux\n\npackage p"},
+		{"tag2.go", "// Copyright\n\n// +build !linux\n\npackage p"},
+		{"tag3.go", "// +build foo\n// +build amd64\n\npackage p"},
+		{"tag4.go", "/* a */ // +build windows\n\npackage p // not at line start"},
+		{"tag5.go", "// +build windows\npackage p // no blank line"},
+		{"_ignored.go", "package p"},
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, s := range sources {
+		f, err := parser.ParseFile(fset, "/src/p/"+s.filename, s.src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	for _, test := range []struct {
+		goos, goarch string
+		tags         []string
+		want         string
+	}{
+		{"linux", "amd64", nil, "all.go p_linux.go tag1.go tag4.go tag5.go"},
+		{"linux", "386", nil, "all.go p_linux.go p_linux_386.go tag1.go tag4.go tag5.go"},
+		{"windows", "amd64", nil, "all.go p_windows.go tag2.go tag4.go tag5.go"},
+		{"darwin", "amd64", []string{"foo"}, "all.go tag2.go tag3.go tag4.go tag5.go"},
+		{"darwin", "386", []string{"foo"}, "all.go tag2.go tag4.go tag5.go"},
+	} {
+		ctxt := build.Default
+		ctxt.GOOS = test.goos
+		ctxt.GOARCH = test.goarch
+		ctxt.BuildTags = test.tags
+
+		var got string
+		for _, f := range buildutil.FilterFiles(fset, &ctxt, files) {
+			if got != "" {
+				got += " "
+			}
+			got += fset.Position(f.Package).Filename[len("/src/p/"):]
+		}
+		if got != test.want {
+			t.Errorf("%s/%s %v: got %s; want %s", test.goos, test.goarch, test.tags, got, test.want)
+		}
+	}
+}
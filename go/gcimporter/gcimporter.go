@@ -115,6 +115,10 @@ func ImportData(imports map[string]*types.Package, filename, id string, data io.
 // Local import paths are interpreted relative to the current working directory.
 // The imports map must contains all packages already imported.
 //
+// Import is not safe for concurrent use with the same imports map;
+// to type-check several packages concurrently with a shared map, use
+// types.SerialImporter(Import) (see types.Config).
+//
 func Import(imports map[string]*types.Package, path string) (pkg *types.Package, err error) {
 	if path == "unsafe" {
 		return types.Unsafe, nil
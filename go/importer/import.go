@@ -7,6 +7,11 @@
 // by R. Griesemer, Technical Report 156, ETH Zürich, 1991.
 
 // package importer implements an exporter and importer for Go export data.
+// The data produced by ExportData captures the exported objects of a
+// package, including constant values, methods, and references to objects
+// of other packages; ImportData reconstructs the package from it, reusing
+// already imported packages. Together they may be used to cache type
+// information across runs without re-checking a package's dependencies.
 package importer
 
 import (
@@ -98,6 +98,76 @@ func TestImportSrc(t *testing.T) {
 	}
 }
 
+func TestImportCrossPackage(t *testing.T) {
+	const srcA = `package a
+type T struct{ x int }
+func (T) M() int
+func (*T) m()
+type I interface{ M() int }
+const C = 1 << 100 >> 98`
+
+	const srcB = `package b
+import "a"
+type U struct{ a.T; p *a.T }
+var V map[a.I][]a.T
+func F(a.I, ...*a.T) (a.T, error)
+const D = a.C * 2.5`
+
+	imports := make(map[string]*types.Package)
+	conf := types.Config{
+		Packages: imports,
+		Import: func(imports map[string]*types.Package, path string) (*types.Package, error) {
+			return imports[path], nil
+		},
+	}
+	check := func(path, src string) *types.Package {
+		f, err := parser.ParseFile(fset, path, src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg, err := conf.Check(path, fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		imports[path] = pkg
+		return pkg
+	}
+	pkgA := check("a", srcA)
+	pkgB0 := check("b", srcB)
+
+	// Import b into an environment that already contains a;
+	// references to a must resolve to the existing objects.
+	data := ExportData(pkgB0)
+	imports = map[string]*types.Package{"a": pkgA}
+	_, pkgB1, err := ImportData(imports, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s0, s1 := pkgString(pkgB0), pkgString(pkgB1); s1 != s0 {
+		t.Errorf("import got:\n%s\nwant:\n%s", s1, s0)
+	}
+
+	for _, name := range []string{"V", "F", "D"} {
+		obj0 := pkgB0.Scope().Lookup(name)
+		obj1 := pkgB1.Scope().Lookup(name)
+		if obj1 == nil {
+			t.Errorf("%s not found", name)
+			continue
+		}
+		if !types.Identical(obj0.Type(), obj1.Type()) {
+			t.Errorf("%s: got type %s; want %s", name, obj1.Type(), obj0.Type())
+		}
+	}
+	U0 := pkgB0.Scope().Lookup("U").Type().Underlying()
+	U1 := pkgB1.Scope().Lookup("U").Type().Underlying()
+	if !types.Identical(U0, U1) {
+		t.Errorf("U: got underlying type %s; want %s", U1, U0)
+	}
+	if got, want := pkgB1.Scope().Lookup("D").(*types.Const).Val().String(), "10"; got != want {
+		t.Errorf("D: got value %s; want %s", got, want)
+	}
+}
+
 func TestImportStdLib(t *testing.T) {
 	start := time.Now()
 
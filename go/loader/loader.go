@@ -493,15 +493,28 @@ func (prog *Program) InitialPackages() []*PackageInfo {
 
 // importer holds the working state of the algorithm.
 type importer struct {
-	conf     *Config                // the client configuration
-	prog     *Program               // resulting program
-	imported map[string]*importInfo // all imported packages (incl. failures) by import path
+	conf      *Config                // the client configuration
+	prog      *Program               // resulting program
+	imported  map[string]*importInfo // all imported packages (incl. failures) by import path
+	importing []string               // import paths of the imports in progress, innermost last
 }
 
 // importInfo tracks the success or failure of a single import.
 type importInfo struct {
-	info *PackageInfo // results of typechecking (including errors)
-	err  error        // reason for failure to make a package
+	info      *PackageInfo // results of typechecking (including errors)
+	err       error        // reason for failure to make a package
+	importing bool         // set while the import is in progress
+}
+
+// An ImportCycleError is reported for an import that is part of an
+// import cycle. Path lists the import paths along the cycle; the first
+// and last entries are the same.
+type ImportCycleError struct {
+	Path []string
+}
+
+func (e *ImportCycleError) Error() string {
+	return "import cycle not allowed: " + strings.Join(e.Path, " -> ")
 }
 
 // Load creates the initial packages specified by conf.{Create,Import}Pkgs,
@@ -737,6 +750,16 @@ func (imp *importer) doImport(imports map[string]*types.Package, path string) (*
 	}
 
 	info, err := imp.importPackage(path)
+	if err, ok := err.(*ImportCycleError); ok {
+		// Return an empty placeholder together with the error so that the
+		// importing package is checked without follow-up errors for uses
+		// of the imported package (see types.Importer).
+		var name string
+		if info != nil {
+			name = info.Pkg.Name()
+		}
+		return types.NewPackage(path, name), err
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -755,14 +778,19 @@ func (imp *importer) doImport(imports map[string]*types.Package, path string) (*
 //
 // Precondition: path != "unsafe".
 //
+// If the import is part of an import cycle, importPackage returns an
+// *ImportCycleError and the (incomplete) PackageInfo of the package
+// being imported, if it was created already.
+//
 func (imp *importer) importPackage(path string) (*PackageInfo, error) {
 	ii, ok := imp.imported[path]
 	if !ok {
-		// In preorder, initialize the map entry to a cycle
-		// error in case importPackage(path) is called again
+		// In preorder, mark the import as in progress
+		// in case importPackage(path) is called again
 		// before the import is completed.
-		ii = &importInfo{err: fmt.Errorf("import cycle in package %s", path)}
+		ii = &importInfo{importing: true}
 		imp.imported[path] = ii
+		imp.importing = append(imp.importing, path)
 
 		// Find and create the actual package.
 		if _, ok := imp.conf.ImportPkgs[path]; ok || imp.conf.SourceImports {
@@ -773,11 +801,28 @@ func (imp *importer) importPackage(path string) (*PackageInfo, error) {
 		if ii.info != nil {
 			ii.info.Importable = true
 		}
+
+		imp.importing = imp.importing[:len(imp.importing)-1]
+		ii.importing = false
+	} else if ii.importing {
+		return ii.info, imp.cycleError(path)
 	}
 
 	return ii.info, ii.err
 }
 
+// cycleError returns the error for an import of path while path is
+// being imported.
+func (imp *importer) cycleError(path string) *ImportCycleError {
+	for i, p := range imp.importing {
+		if p == path {
+			cycle := append([]string(nil), imp.importing[i:]...)
+			return &ImportCycleError{append(cycle, path)}
+		}
+	}
+	return &ImportCycleError{[]string{path, path}}
+}
+
 // importFromBinary implements package loading from the client-supplied
 // external source, e.g. object files from the gc compiler.
 //
@@ -806,6 +851,7 @@ func (imp *importer) importFromSource(path string) (*PackageInfo, error) {
 	}
 	// Type-check the package.
 	info := imp.newPackageInfo(path)
+	imp.imported[path].info = info // make the package's name available to import cycles
 	files, errs := imp.conf.parsePackageFiles(bp, 'g')
 	for _, err := range errs {
 		info.appendError(err)
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"golang.org/x/tools/go/loader"
+	"golang.org/x/tools/go/types"
 )
 
 func loadFromArgs(args []string) (prog *loader.Program, rest []string, err error) {
@@ -257,3 +258,47 @@ func TestErrorReporting(t *testing.T) {
 		t.Errorf("allErrors = %v, want both syntax and type errors", allErrors)
 	}
 }
+
+func TestImportCycle(t *testing.T) {
+	// a --> b --> c --> b is a cycle; c's uses of b are not reported.
+	pkgs := map[string]string{
+		"a": `package a; import "b"; var A = b.B`,
+		"b": `package b; import "c"; var B int = c.C`,
+		"c": `package c; import "b"; var C = b.B; var D int = "d"`,
+	}
+	conf := loader.Config{
+		AllowErrors:   true,
+		SourceImports: true,
+		Build:         fakeContext(pkgs),
+	}
+	var allErrors []string
+	conf.TypeChecker.Error = func(err error) {
+		allErrors = append(allErrors, err.(types.Error).Msg)
+	}
+	conf.Import("a")
+
+	prog, err := conf.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %s", err)
+	}
+
+	want := []string{
+		"could not import b (import cycle not allowed: b -> c -> b)",
+		`cannot convert "d" (untyped string constant) to int`,
+	}
+	if fmt.Sprint(allErrors) != fmt.Sprint(want) {
+		t.Errorf("got errors %q; want %q", allErrors, want)
+	}
+
+	// The non-cyclic parts are checked.
+	for pkg, info := range prog.AllPackages {
+		if pkg.Path() == "a" {
+			if len(info.Errors) != 0 {
+				t.Errorf("a.Errors = %v; want none", info.Errors)
+			}
+			if got := pkg.Scope().Lookup("A").Type().String(); got != "int" {
+				t.Errorf("a.A has type %s; want int", got)
+			}
+		}
+	}
+}
@@ -27,6 +27,10 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"io"
+	"sort"
+	"strings"
+	"sync"
 
 	"golang.org/x/tools/go/exact"
 )
@@ -50,11 +54,46 @@ func Check(path string, fset *token.FileSet, files []*ast.File) (*Package, error
 // A "soft" error is an error that still permits a valid interpretation of a
 // package (such as "unused variable"); "hard" errors may lead to unpredictable
 // behavior if ignored.
+//
+// Diagnostics of a severity other than SeverityError are advisory: they are
+// reported by opt-in checks (such as Config.ReportShadowing), they are
+// always soft, and they do not cause the type-check to fail.
+//
+// For errors reporting that a constant is not representable by a type (such
+// as "constant 256 overflows byte" for var b byte = 256), Value is the value
+// of the constant and Target is the type, as written (e.g., a named type);
+// otherwise they are nil.
 type Error struct {
-	Fset *token.FileSet // file set for interpretation of Pos
-	Pos  token.Pos      // error position
-	Msg  string         // error message
-	Soft bool           // if set, error is "soft"
+	Fset     *token.FileSet // file set for interpretation of Pos
+	Pos      token.Pos      // error position
+	Msg      string         // error message
+	Soft     bool           // if set, error is "soft"
+	Severity Severity       // diagnostic severity; the zero value is SeverityError
+	Value    exact.Value    // unrepresentable constant value, or nil
+	Target   Type           // type not representing Value, or nil
+}
+
+// Severity describes the severity of an Error.
+type Severity int
+
+// The severities of Errors.
+const (
+	SeverityError   Severity = iota // type error
+	SeverityWarning                 // advisory about likely mistakes
+	SeverityInfo                    // informational advisory
+)
+
+var severityNames = [...]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityInfo:    "info",
+}
+
+func (s Severity) String() string {
+	if 0 <= s && int(s) < len(severityNames) {
+		return severityNames[s]
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
 }
 
 // Error returns an error string formatted as follows:
@@ -73,16 +112,164 @@ func (err Error) Error() string {
 // the importer must load the package data for the given path
 // into a new *Package, record it in imports map, and return
 // the package.
+//
+// The Importer is invoked once for each import declaration, in source
+// order, since the package name must be known to resolve the package's
+// uses. To avoid loading all members of a package up front, the importer
+// may return a package whose scope resolves members on demand (see
+// Scope.SetResolver); members that cannot be resolved are reported as
+// "not declared by package" errors, as for any other package.
+//
+// If the importer cannot provide the package but wants type-checking
+// of the importing package to proceed without follow-up errors (for
+// instance, because the import is part of an import cycle), it may
+// return a package with the correct package name together with a non-nil
+// error. The error is reported, and only the name of the returned package
+// is used: the type checker declares a new, empty placeholder package with
+// that name like a regular import, but qualified identifiers referring to
+// it are not reported as errors and denote invalid operands. The returned
+// package itself is not modified, and the placeholder is not included in
+// the list of imports (see Package.Imports).
+//
 // TODO(gri) Need to be clearer about requirements of completeness.
 type Importer func(map[string]*Package, string) (*Package, error)
 
+// ChainImporters returns an Importer that tries each of the given
+// importers in order and returns the result of the first one that
+// succeeds (that returns a nil error). If all of them fail, the
+// returned error is an ErrorList of the individual errors, and the
+// package is the first package returned with an error, if any. Nil
+// importers are ignored.
+func ChainImporters(importers ...Importer) Importer {
+	return func(imports map[string]*Package, path string) (*Package, error) {
+		var placeholder *Package
+		var errors ErrorList
+		for _, imp := range importers {
+			if imp == nil {
+				continue
+			}
+			pkg, err := imp(imports, path)
+			if err == nil {
+				return pkg, nil
+			}
+			if placeholder == nil {
+				placeholder = pkg
+			}
+			errors.Add(err)
+		}
+		if len(errors) == 0 {
+			return nil, fmt.Errorf("no importer for package %q", path)
+		}
+		return placeholder, errors
+	}
+}
+
+// SerialImporter returns an Importer that invokes imp but serializes
+// its invocations: it waits for any pending invocation to return before
+// invoking imp again. It permits concurrent type-checking of several
+// packages with a shared Config.Packages map (see Config) using an
+// importer that is not safe for concurrent use, such as gcimporter.Import.
+// imp must not invoke the returned importer (for instance, to import
+// the dependencies of a package it type-checks from source); it should
+// invoke itself instead.
+func SerialImporter(imp Importer) Importer {
+	var mu sync.Mutex
+	return func(imports map[string]*Package, path string) (*Package, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return imp(imports, path)
+	}
+}
+
 // A Config specifies the configuration for type checking.
 // The zero value for Config is a ready-to-use default configuration.
+//
+// Independent packages may be type-checked concurrently, by separate
+// calls of Check or by separate Checkers, even if they share a Config
+// or imported packages, as long as the following conditions are met:
+//
+//	- the Config is not modified while in use; in particular, Packages
+//	  must be set since Check sets it otherwise (see Packages);
+//	- calls of the Importer are serialized (see SerialImporter) if they
+//	  share a Packages map or other state; the importer must also not
+//	  modify packages it has returned before (other than by completing
+//	  a package that is not complete yet, as long as that package is
+//	  not used by a concurrent check);
+//	- the Info structs and the result packages are distinct, and no
+//	  package is imported before it was checked completely;
+//	- the scopes of shared packages don't resolve members on demand
+//	  (see Scope.SetResolver) or all of their members were resolved
+//	  (e.g., by calling Scope.Names) before the package is shared.
+//
+// The type checker itself modifies only the package being checked and
+// its Info; it treats imported packages, including packages returned
+// together with an import error (see Importer), as read-only. A single
+// Checker must not be used concurrently.
 type Config struct {
 	// If IgnoreFuncBodies is set, function bodies are not
 	// type-checked.
 	IgnoreFuncBodies bool
 
+	// If ExportedOnly is set, only the exported package-level
+	// declarations and the declarations they depend on are
+	// type-checked; function bodies are not type-checked.
+	// An unexported type (or constant, variable, or function)
+	// referenced from an exported declaration, for instance from
+	// an exported function's signature or a variable initializer,
+	// is type-checked fully, including its methods' signatures.
+	// Unexported declarations that are not reachable this way are
+	// ignored and their objects remain without type. The
+	// initialization order (Info.InitOrder) is not computed and
+	// unused imports are not reported.
+	ExportedOnly bool
+
+	// If NoInitOrder is set, the initialization order of package-level
+	// variables is not computed, which saves building the dependency
+	// graph of package-level objects: Info.InitOrder remains empty and
+	// initialization cycles are not reported. All other checks are
+	// performed as usual.
+	NoInitOrder bool
+
+	// If PackageName is set, it is the name expected to be declared by
+	// the package clause of each file of a package whose name is not yet
+	// known (which is the case for Check); a PackageName of _ is reported
+	// as an error and ignored. Otherwise, the package name is determined
+	// by the first file (with a package name other than _). Files declaring
+	// a different package name are reported with an error and ignored. In
+	// particular, the files of an external test package (whose package name
+	// ends in "_test") are not part of the package; see CheckWithTests.
+	PackageName string
+
+	// If ReplaceDeclarations is set, a package-level object declared in
+	// the files passed to a call of Checker.Files replaces an object with
+	// the same name declared by an earlier call of Files for the same
+	// package (as the successive inputs of an interactive session would),
+	// rather than being reported as a redeclaration. Redeclarations within
+	// the files of a single call are still reported.
+	//
+	// A replaced object is removed from the package scope, but objects
+	// checked earlier are not checked again: they continue to refer to the
+	// replaced objects they were resolved to, with their types and constant
+	// values, while later references resolve to the new object. In particular,
+	// a replacing type declaration declares a new type, which is different
+	// from the replaced type (and which only has the methods declared with
+	// it); and package-level variables that were replaced remain part of the
+	// package's initialization order (Info.InitOrder). Clients that want
+	// dependent declarations to use the new object must provide them again.
+	ReplaceDeclarations bool
+
+	// If ReportShadowing is set, a warning (an Error of SeverityWarning)
+	// is reported for each declaration (other than of the blank identifier)
+	// that shadows a predeclared identifier such as len, error, or true.
+	ReportShadowing bool
+
+	// If LenientUnsafe is set, invalid conversions from or to
+	// unsafe.Pointer are reported as soft errors, and the result
+	// of such a conversion is assumed to be a value of the target
+	// type. This permits collecting type information for code that
+	// pushes the boundaries of package unsafe.
+	LenientUnsafe bool
+
 	// If FakeImportC is set, `import "C"` (for packages requiring Cgo)
 	// declares an empty "C" package and errors are omitted for qualified
 	// identifiers referring to package C (which won't find an object).
@@ -94,7 +281,9 @@ type Config struct {
 
 	// Packages is used to look up (and thus canonicalize) packages by
 	// package path. If Packages is nil, it is set to a new empty map.
-	// During type-checking, imported packages are added to the map.
+	// During type-checking, imported packages are added to the map
+	// (by the Importer). Concurrent checks sharing the map must
+	// serialize their imports (see Config).
 	Packages map[string]*Package
 
 	// If Error != nil, it is called with each error found
@@ -103,7 +292,9 @@ type Config struct {
 	// involved in an invalid recursive type declaration) have
 	// error strings that start with a '\t' character.
 	// If Error == nil, type-checking stops with the first
-	// error found.
+	// error found. Warnings and other advisory diagnostics
+	// (see Severity) are reported via Error as well; they are
+	// dropped if Error == nil.
 	Error func(err error)
 
 	// If Import != nil, it is called for each imported package.
@@ -112,7 +303,72 @@ type Config struct {
 
 	// If Sizes != nil, it provides the sizing functions for package unsafe.
 	// Otherwise &StdSizes{WordSize: 8, MaxAlign: 8} is used instead.
+	// The sizes of int, uint, and uintptr also determine which constant
+	// values are representable by (and thus overflow) these types.
 	Sizes Sizes
+
+	// If Universe != nil, identifiers that cannot be resolved in the
+	// scope in which they appear (including the Universe scope) are
+	// looked up in Universe and its parent scopes before an "undeclared
+	// name" error is reported. This permits checking code that refers
+	// to additional predeclared objects (for instance, for Go-like
+	// DSLs) without modifying the package-global Universe scope.
+	// Predeclared objects and declarations in the package or any
+	// nested scope take precedence over objects in Universe.
+	Universe *Scope
+
+	// If Resolve != nil, it is called with the name of each identifier
+	// that cannot be resolved in the scope in which it appears (nor in
+	// Universe, if set) and with that scope, before an "undeclared name"
+	// error is reported. It is not called for the blank identifier. If
+	// Resolve returns a non-nil object, the identifier denotes that object,
+	// which must have a (non-nil) type. Otherwise the identifier remains
+	// undeclared and the error is reported as usual. This permits checking
+	// templated code that refers to symbols injected by a code generator.
+	Resolve func(name string, scope *Scope) Object
+
+	// If Trace != nil, a human-readable trace of the type checker's
+	// decisions is written to it: the declarations of package-level
+	// objects, the evaluation of types and expressions (including their
+	// constant values), and the resolution of selectors, each prefixed
+	// by its position. The trace format is not stable; it is intended
+	// for debugging only.
+	Trace io.Writer
+
+	// If Observer != nil, it is notified of the type-checking results
+	// recorded in the Info maps Types, Defs, Uses, Implicits, Selections,
+	// and Scopes as they are determined, whether or not the respective
+	// maps are provided. This permits clients to extract the information
+	// they need without retaining the maps (see Observer).
+	Observer Observer
+
+	// If OnPackageChecked != nil, it is called with the resulting package
+	// at the end of each successful call of Check, once the Info maps
+	// provided to Check are fully populated. It is not called if Check
+	// returns an error. Since CheckWithTests and CheckVariants check each
+	// package via Check, it is called once for each package they check.
+	// Clients may use it to release per-package resources or to start
+	// dependent work as soon as a package is complete.
+	OnPackageChecked func(pkg *Package)
+}
+
+// An Observer is notified of type-checking results (see Config.Observer).
+// Each method corresponds to the Info map of the same name: a call of
+// OnType(x, tv) corresponds to the map entry Types[x] = tv, and so on.
+//
+// OnType may be called more than once for the same expression; the last
+// call reports the final result. For instance, the types of untyped
+// expressions are reported only once their final types are known
+// (at the end of type checking), and the type of a comma-ok expression
+// is updated to a tuple type once its use in a comma-ok assignment is
+// known.
+type Observer interface {
+	OnType(x ast.Expr, tv TypeAndValue)
+	OnDef(id *ast.Ident, obj Object)
+	OnUse(id *ast.Ident, obj Object)
+	OnImplicit(node ast.Node, obj Object)
+	OnSelection(x *ast.SelectorExpr, sel *Selection)
+	OnScope(node ast.Node, scope *Scope)
 }
 
 // DefaultImport is the default importer invoked if Config.Import == nil.
@@ -129,7 +385,12 @@ var DefaultImport Importer
 // be incomplete.
 type Info struct {
 	// Types maps expressions to their types, and for constant
-	// expressions, their values. Invalid expressions are omitted.
+	// expressions, their values. Invalid expressions are omitted,
+	// with the exception of undeclared identifiers: they are recorded
+	// with type Typ[Invalid] as placeholders for partially correct
+	// code. Unless such an identifier appears in a type expression
+	// (where IsType reports true), all TypeAndValue predicates report
+	// false for it.
 	//
 	// For (possibly parenthesized) identifiers denoting built-in
 	// functions, the recorded signatures are call-site specific:
@@ -137,6 +398,31 @@ type Info struct {
 	// an argument-specific signature. Otherwise, the recorded type
 	// is invalid.
 	//
+	// The type recorded for a call of a function with multiple results is
+	// the result *Tuple; for a call without results it is (*Tuple)(nil),
+	// the empty tuple, and IsVoid reports true. Comma-ok expressions
+	// (map index expressions, type assertions, and receive operations)
+	// that provide both values of a two-valued assignment, initialization,
+	// or select case are recorded with a *Tuple type (T, B) where T is the
+	// type of the first value and B is the (boolean) type of the second
+	// value; if they provide a single value, their recorded type is T. For
+	// parenthesized comma-ok expressions, the same tuple type is recorded
+	// for each of the (nested) parenthesized expressions.
+	//
+	// Constant expressions are not only recorded as a whole: each of their
+	// operands, including parenthesized expressions and the operands of
+	// nested operations and conversions, is recorded with its own type and
+	// constant value, which permits following the folding step by step.
+	// Untyped operands of a constant operation are recorded with their
+	// untyped types and exact values; only the outermost expression
+	// assumes its final type (and possibly rounded value). The argument
+	// of a constant conversion T(x) is recorded with the rounded value
+	// it has as an operand of type T. For instance,
+	// in the declaration var x int64 = (1 + 2) * 3, the expression
+	// (1 + 2) * 3 is recorded with type int64 and value 9, while 1, 2,
+	// (1 + 2), 1 + 2, and 3 are recorded as untyped int constants with
+	// values 1, 2, 3, 3, and 3.
+	//
 	// Identifiers on the lhs of declarations (i.e., the identifiers
 	// which are being declared) are collected in the Defs map.
 	// Identifiers denoting packages are collected in the Uses maps.
@@ -171,10 +457,96 @@ type Info struct {
 	//
 	Implicits map[ast.Node]Object
 
+	// PkgNames maps identifiers denoting objects of imported packages to
+	// the package names (imports) through which they are resolved: for a
+	// qualified identifier p.x, the identifier x maps to the *PkgName
+	// denoted by p (which is recorded in Uses); a dot-imported identifier
+	// maps to the *PkgName of the respective dot-import.
+	PkgNames map[*ast.Ident]*PkgName
+
+	// DotImports maps identifiers that resolve to objects imported via
+	// a dot-import (import . "path") to the respective imported package.
+	// The corresponding entries in Uses denote the imported objects, and
+	// those in PkgNames denote the dot-imports.
+	DotImports map[*ast.Ident]*Package
+
+	// FileImports maps each package file to its imports (see FileImports).
+	FileImports map[*ast.File]*FileImports
+
 	// Selections maps selector expressions (excluding qualified identifiers)
 	// to their corresponding selections.
 	Selections map[*ast.SelectorExpr]*Selection
 
+	// Conversions maps expressions whose values are implicitly converted
+	// to an interface type (in assignments, initializations, function
+	// arguments, return statements, etc.) to the pair (source type,
+	// target interface type). Values of interface type and the untyped
+	// nil are not recorded since they don't undergo a conversion.
+	Conversions map[ast.Expr][2]Type
+
+	// UntypedConversions maps untyped expressions whose values are
+	// implicitly converted to a typed type to the pair (untyped type,
+	// target type). Such conversions happen, for instance, when an
+	// untyped value is assigned to a typed variable, combined with a
+	// typed operand in a binary operation, or used where its default
+	// type is required (e.g., when assigned to an interface). Only
+	// the outermost expression of a converted untyped expression is
+	// recorded. The untyped nil is not recorded since it assumes no
+	// type, and explicit conversions are recorded in Types only.
+	UntypedConversions map[ast.Expr][2]Type
+
+	// Assertions maps type assertions to their static (interface) operand
+	// type From and their asserted type To. For a type assertion x.(T),
+	// the *ast.TypeAssertExpr is recorded; for a type switch, each type
+	// T listed in a case clause is recorded (using T's expression as key)
+	// with the type of the type switch guard's operand x. The nil case
+	// and invalid types are not recorded. Assertions that cannot succeed
+	// are recorded as well; they are also reported as errors.
+	Assertions map[ast.Expr]struct{ From, To Type }
+
+	// RedundantConversions records the explicit conversions T(x) whose
+	// operand x already has type T, such that the conversion could be
+	// removed without changing the meaning of the program. Only such
+	// conversions are recorded, with the value true. Conversions of
+	// untyped operands are never redundant: they determine the type
+	// of the operand.
+	RedundantConversions map[*ast.CallExpr]bool
+
+	// CallKind maps call expressions to their kind: conversions T(x),
+	// calls of built-in functions, and calls of function or method
+	// values. A call is recorded as soon as its callee is known, even
+	// if its arguments are invalid; calls with an invalid callee or
+	// a callee of non-function type are not recorded.
+	CallKind map[*ast.CallExpr]CallKind
+
+	// CompositeLitTypes maps the elements of composite literals to the
+	// types they are expected to have: for a struct literal, the value
+	// of each element maps to the type of the respective field; for an
+	// array or slice literal, the value of each element maps to the
+	// element type; and for a map literal, the key and the value of each
+	// element map to the key and element type of the map. Keys of struct,
+	// array, and slice literals are not recorded. Elements of nested
+	// literals, including those whose type is elided, are recorded as well.
+	CompositeLitTypes map[ast.Expr]Type
+
+	// UnusedResults maps function call expressions whose results are
+	// discarded, partly or entirely, to a slice reporting for each result
+	// of the call whether it is discarded. All results are discarded if
+	// the call appears in statement context (expression, go, and defer
+	// statements); a result is also considered discarded if it is assigned
+	// to the blank identifier. Calls without results, and calls whose
+	// results are all used, are not recorded.
+	UnusedResults map[*ast.CallExpr][]bool
+
+	// Unused is the list of local variables that are declared but never
+	// used, in the order in which the respective "declared but not used"
+	// errors are reported. Parameters and results are never unused, and
+	// blank variables are not declared and thus never listed. If the
+	// variable declared by a type switch guard is not used in any clause,
+	// the variables implicitly declared for each clause are listed (see
+	// Implicits).
+	Unused []*Var
+
 	// Scopes maps ast.Nodes to the scopes they define. Package scopes are not
 	// associated with a specific node but with all files belonging to a package.
 	// Thus, the package scope can be found in the type-checked Package object.
@@ -203,13 +575,17 @@ type Info struct {
 	// InitOrder is the list of package-level initializers in the order in which
 	// they must be executed. Initializers referring to variables related by an
 	// initialization dependency appear in topological order, the others appear
-	// in source order. Variables without an initialization expression do not
-	// appear in this list.
+	// in source order. If a package consists of multiple files, source order
+	// is the order in which the files are presented to the type checker and,
+	// within each file, the order of the declarations in the file; thus the
+	// InitOrder is deterministic. Variables without an initialization
+	// expression do not appear in this list.
 	InitOrder []*Initializer
 }
 
 // TypeOf returns the type of expression e, or nil if not found.
 // Precondition: the Types, Uses and Defs maps are populated.
+// Maps that are nil are treated as empty.
 //
 func (info *Info) TypeOf(e ast.Expr) Type {
 	if t, ok := info.Types[e]; ok {
@@ -230,6 +606,7 @@ func (info *Info) TypeOf(e ast.Expr) Type {
 // it uses, not the type (*TypeName) it defines.
 //
 // Precondition: the Uses and Defs maps are populated.
+// Maps that are nil are treated as empty.
 //
 func (info *Info) ObjectOf(id *ast.Ident) Object {
 	if obj, _ := info.Defs[id]; obj != nil {
@@ -277,6 +654,13 @@ func (tv TypeAndValue) IsValue() bool {
 	return false
 }
 
+// IsConst reports whether the corresponding expression is a constant
+// expression. Its Value is the (possibly rounded) constant value; for
+// instance, float64(1e-2000) is a constant expression with value 0.
+func (tv TypeAndValue) IsConst() bool {
+	return tv.mode == constant
+}
+
 // IsNil reports whether the corresponding expression denotes the
 // predeclared value nil.
 func (tv TypeAndValue) IsNil() bool {
@@ -301,9 +685,38 @@ func (tv TypeAndValue) HasOk() bool {
 	return tv.mode == commaok || tv.mode == mapindex
 }
 
+// CallKind describes the kind of a call expression f(x).
+type CallKind int
+
+const (
+	FuncCall    CallKind = iota // f(x) is a call of a function or method value
+	Conversion                  // f(x) is a type conversion
+	BuiltinCall                 // f(x) is a call of a built-in function
+)
+
+// A FileImports describes the imports of a package file.
+//
+// Declared lists the package names declared by the file's import
+// declarations, in source order, including those of blank imports (named
+// "_") and dot-imports (named "."); an aliased import is represented by a
+// *PkgName with the alias as name. Used lists the subset of Declared
+// that is used in the file: a package name is used if a qualified
+// identifier refers to it, and a dot-import is used if any of the objects
+// it imports is referred to. Blank imports are never used. If function
+// bodies are not checked (Config.IgnoreFuncBodies), Used may be incomplete.
+type FileImports struct {
+	Declared []*PkgName
+	Used     []*PkgName
+}
+
 // An Initializer describes a package-level variable, or a list of variables in case
 // of a multi-valued initialization expression, and the corresponding initialization
 // expression.
+//
+// Lhs holds the variables in the order in which they are declared; blank (_)
+// variables are represented by *Var objects named "_" and have no entry in
+// the package scope. Rhs is the initialization expression as it appears in
+// the source; for a list of variables it is a single multi-valued expression.
 type Initializer struct {
 	Lhs []*Var // var Lhs = Rhs
 	Rhs ast.Expr
@@ -333,7 +746,142 @@ func (init *Initializer) String() string {
 // The clean path must not be empty or dot (".").
 func (conf *Config) Check(path string, fset *token.FileSet, files []*ast.File, info *Info) (*Package, error) {
 	pkg := NewPackage(path, "")
-	return pkg, NewChecker(conf, fset, pkg, info).Files(files)
+	if err := NewChecker(conf, fset, pkg, info).Files(files); err != nil {
+		return pkg, err
+	}
+	if conf.OnPackageChecked != nil {
+		conf.OnPackageChecked(pkg)
+	}
+	return pkg, nil
+}
+
+// CheckWithTests type-checks a package together with its test files
+// and returns the resulting package objects, the first error if any,
+// and if info != nil, additional type information for both packages.
+//
+// The files may contain the files of the package proper, its internal
+// test files (which declare the same package name), and the files of
+// the external test package (which declare the package name with the
+// suffix "_test"), in any order; the package name of the package proper
+// is Config.PackageName if set, and otherwise the name of the first file
+// whose package name doesn't end in "_test".
+// The package proper, including its internal test files, is checked
+// first and identified with path. The external test package, if there
+// are any such files, is identified with path + "_test"; its imports of
+// path denote the package proper. If there are no external test files,
+// xtest is nil.
+//
+// As for Check, errors are reported via Config.Error, if set; otherwise
+// checking stops at the first error, and the external test package is
+// not checked if there were errors in the package proper.
+func (conf *Config) CheckWithTests(path string, fset *token.FileSet, files []*ast.File, info *Info) (pkg, xtest *Package, err error) {
+	name := conf.PackageName
+	if name == "" || name == "_" {
+		name = ""
+		for _, f := range files {
+			if !strings.HasSuffix(f.Name.Name, "_test") {
+				name = f.Name.Name
+				break
+			}
+		}
+	}
+
+	var pkgFiles, xtestFiles []*ast.File
+	for _, f := range files {
+		if name != "" && f.Name.Name == name+"_test" {
+			xtestFiles = append(xtestFiles, f)
+		} else {
+			pkgFiles = append(pkgFiles, f)
+		}
+	}
+
+	pkg, err = conf.Check(path, fset, pkgFiles, info)
+	if len(xtestFiles) == 0 || err != nil && conf.Error == nil {
+		return
+	}
+
+	importer := conf.importer()
+	xconf := *conf
+	xconf.PackageName = name + "_test"
+	xconf.Import = func(imports map[string]*Package, ipath string) (*Package, error) {
+		if ipath == path {
+			return pkg, nil
+		}
+		return importer(imports, ipath)
+	}
+	xtest, xerr := xconf.Check(path+"_test", fset, xtestFiles, info)
+	if err == nil {
+		err = xerr
+	}
+	return
+}
+
+// CheckVariants type-checks several variants of a package, such as the
+// sets of files selected by different build tags, and returns the resulting
+// package object for each variant key, and the first error if any.
+//
+// Each variant consists of the files common to all variants and the files
+// listed for its key in variants; it is checked as a separate package
+// identified with path, in sorted key order. Consequently, the package-level
+// objects (and the types they denote) declared in the common files are
+// distinct objects in each variant package: they have the same names and
+// positions, but they are not identical across variants, and a package-level
+// name may denote different kinds of objects, or be missing altogether, in
+// different variants. Objects should be correlated by name (or position),
+// not by identity.
+//
+// All variants share the same Config.Packages map, which is created if
+// necessary; thus each imported package is imported only once. If
+// Config.Error is set, it is called only once for any error that is
+// reported identically (e.g. for a common file) by more than one variant.
+// Otherwise, as for Check, checking of a variant stops at its first error,
+// but the remaining variants are still checked.
+func (conf *Config) CheckVariants(path string, fset *token.FileSet, common []*ast.File, variants map[string][]*ast.File) (map[string]*Package, error) {
+	keys := make([]string, 0, len(variants))
+	for key := range variants {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	vconf := *conf
+	if vconf.Packages == nil {
+		vconf.Packages = make(map[string]*Package)
+	}
+	if f := conf.Error; f != nil {
+		seen := make(map[string]bool)
+		vconf.Error = func(err error) {
+			if msg := err.Error(); !seen[msg] {
+				seen[msg] = true
+				f(err)
+			}
+		}
+	}
+
+	pkgs := make(map[string]*Package, len(keys))
+	var firstErr error
+	for _, key := range keys {
+		files := append(common[:len(common):len(common)], variants[key]...)
+		pkg, err := vconf.Check(path, fset, files, nil)
+		pkgs[key] = pkg
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return pkgs, firstErr
+}
+
+// importer returns the importer to be used for conf.
+func (conf *Config) importer() Importer {
+	if conf.Import != nil {
+		return conf.Import
+	}
+	if DefaultImport != nil {
+		return DefaultImport
+	}
+	// Panic if we encounter an import.
+	return func(map[string]*Package, string) (*Package, error) {
+		panic(`no Config.Import or DefaultImport (missing import _ "golang.org/x/tools/go/gcimporter"?)`)
+	}
 }
 
 // AssertableTo reports whether a value of type V can be asserted to have type T.
@@ -345,7 +893,21 @@ func AssertableTo(V *Interface, T Type) bool {
 // AssignableTo reports whether a value of type V is assignable to a variable of type T.
 func AssignableTo(V, T Type) bool {
 	x := operand{mode: value, typ: V}
-	return x.assignableTo(nil, T) // config not needed for non-constant x
+	return x.assignableTo(nil, T, nil) // config not needed for non-constant x
+}
+
+// AssignableToReason is like AssignableTo but if a value of type V is
+// not assignable to a variable of type T, it also returns a description
+// of the incompatibility, such as "missing method m" or "channel
+// directions differ". The description may be empty if no specific
+// reason can be given.
+func AssignableToReason(V, T Type) (ok bool, reason string) {
+	x := operand{mode: value, typ: V}
+	ok = x.assignableTo(nil, T, &reason) // config not needed for non-constant x
+	if ok {
+		reason = ""
+	}
+	return
 }
 
 // ConvertibleTo reports whether a value of type V is convertible to a value of type T.
@@ -354,6 +916,22 @@ func ConvertibleTo(V, T Type) bool {
 	return x.convertibleTo(nil, T) // config not needed for non-constant x
 }
 
+// Representable reports whether the constant value x can be represented
+// as a value of type typ without overflow. Floating-point and complex
+// values that fit the range of typ are considered representable even if
+// they must be rounded. Only types whose underlying type is a basic type
+// can represent constants; for all other types the result is false.
+// The sizes of int, uint, and uintptr are those used if Config.Sizes == nil
+// (64 bits).
+func Representable(x exact.Value, typ Type) bool {
+	t, _ := typ.Underlying().(*Basic)
+	if t == nil {
+		return false
+	}
+	var conf Config
+	return representableConst(x, &conf, t.kind, nil)
+}
+
 // Implements reports whether type V implements interface T.
 func Implements(V Type, T *Interface) bool {
 	f, _ := MissingMethod(V, T, true)
@@ -10,9 +10,12 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 
+	"golang.org/x/tools/go/exact"
 	_ "golang.org/x/tools/go/gcimporter"
 	. "golang.org/x/tools/go/types"
 )
@@ -143,6 +146,46 @@ func TestValuesInfo(t *testing.T) {
 		if got := tv.Value.String(); got != test.val {
 			t.Errorf("package %s: got value %s; want %s", name, got, test.val)
 		}
+
+		// check that expression is classified as constant
+		if !tv.IsConst() {
+			t.Errorf("package %s: %s is not classified as constant", name, test.expr)
+		}
+	}
+}
+
+func TestIsConst(t *testing.T) {
+	const src = `package p
+const c = 1 << 10
+var v = c
+var _ = []int{len([2]int{}), len([]int{}), v + c, int(c)}
+`
+	info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+	mustTypecheck(t, "IsConst", src, &info)
+
+	consts := map[string]bool{
+		"1 << 10":       true,
+		"c":             true,
+		"len([2]int{})": true,
+		"int(c)":        true,
+		"v":             false,
+		"len([]int{})":  false,
+		"v + c":         false,
+		"[]int{}":       false,
+		"int":           false, // type expression
+		"len":           false, // built-in
+	}
+	for e, tv := range info.Types {
+		want, ok := consts[ExprString(e)]
+		if !ok {
+			continue
+		}
+		if got := tv.IsConst(); got != want {
+			t.Errorf("%s: got IsConst() = %v; want %v", ExprString(e), got, want)
+		}
+		if got := tv.Value != nil; got != tv.IsConst() {
+			t.Errorf("%s: got Value = %v; want IsConst() = %v", ExprString(e), tv.Value, tv.IsConst())
+		}
 	}
 }
 
@@ -256,6 +299,268 @@ func TestTypesInfo(t *testing.T) {
 	}
 }
 
+func TestConversionsInfo(t *testing.T) {
+	var tests = []struct {
+		src  string
+		expr string // expression
+		conv string // "from -> to", or "" if no conversion is recorded
+	}{
+		{`package c0; type T struct{}; var p *T; var x interface{} = p`, `p`, `*c0.T -> interface{}`},
+		{`package c1; var x interface{} = 0`, `0`, `int -> interface{}`},
+		{`package c2; var x interface{} = nil`, `nil`, ``},
+		{`package c3; var y interface{}; var x interface{ m() } = nil; var z = y == x`, `x`, ``},
+		{`package c4; type E interface{ Error() string }; type T struct{}; func (*T) Error() string { return "" }
+		  func f() E { var p *T; return p }`, `p`, `*c4.T -> c4.E`},
+		{`package c5; func f(...interface{}); func _() { f(1, "foo") }`, `"foo"`, `string -> interface{}`},
+		{`package c6; var _ = []interface{}{1.0}`, `1.0`, `float64 -> interface{}`},
+		{`package c7; var _ = map[interface{}]int{true: 0}`, `true`, `bool -> interface{}`},
+		{`package c8; var ch chan interface{}; func _() { ch <- 'a' }`, `'a'`, `rune -> interface{}`},
+		{`package c9; var x int; var _ = x`, `x`, ``},
+	}
+
+	for _, test := range tests {
+		info := Info{Conversions: make(map[ast.Expr][2]Type)}
+		name := mustTypecheck(t, "ConversionsInfo", test.src, &info)
+
+		var got string
+		for e, conv := range info.Conversions {
+			if ExprString(e) == test.expr {
+				got = fmt.Sprintf("%s -> %s", conv[0], conv[1])
+				break
+			}
+		}
+		if got != test.conv {
+			t.Errorf("package %s: got %q; want %q", name, got, test.conv)
+		}
+	}
+}
+
+func TestUntypedConversionsInfo(t *testing.T) {
+	var tests = []struct {
+		src   string
+		convs string // sorted list of "expr: from -> to" entries
+	}{
+		{`package u0; var x float64 = 1`, `1: untyped int -> float64`},
+		{`package u1; var x interface{} = 'a'`, `'a': untyped rune -> rune`},
+		{`package u2; var x int; var y = x + 1<<2`, `1 << 2: untyped int -> int`},
+		{`package u3; type T int; func f(T, ...int8); func _() { f(1, 2, 3) }`, `1: untyped int -> u3.T; 2: untyped int -> int8; 3: untyped int -> int8`},
+		{`package u4; var x, y int; var b bool = x < y`, `x < y: untyped bool -> bool`},
+		{`package u5; var s []int = nil; var _ = s == nil`, `s == nil: untyped bool -> bool`},
+		{`package u6; var x = 1; const c = 2; var y = float32(c)`, `1: untyped int -> int`},
+		{`package u7; var x int; var _ = (x == 1) == true`, `(x == 1) == true: untyped bool -> bool; (x == 1): untyped bool -> bool; 1: untyped int -> int; true: untyped bool -> bool`},
+	}
+
+	for _, test := range tests {
+		info := Info{UntypedConversions: make(map[ast.Expr][2]Type)}
+		name := mustTypecheck(t, "UntypedConversionsInfo", test.src, &info)
+
+		var list []string
+		for e, conv := range info.UntypedConversions {
+			list = append(list, fmt.Sprintf("%s: %s -> %s", ExprString(e), conv[0], conv[1]))
+		}
+		sort.Strings(list)
+		if got := strings.Join(list, "; "); got != test.convs {
+			t.Errorf("package %s: got %q; want %q", name, got, test.convs)
+		}
+	}
+}
+
+func TestAssertionsInfo(t *testing.T) {
+	var tests = []struct {
+		src        string
+		assertions string // sorted list of "expr: from -> to" entries
+	}{
+		{`package a0; var x interface{}; var _ = x.(int)`, `x.(int): interface{} -> int`},
+		{`package a1; var x interface{}; var _, _ = x.(string)`, `x.(string): interface{} -> string`},
+		{`package a2; var x error; var _ = x.(interface{ Temporary() bool })`, `x.(interface{Temporary() bool}): error -> interface{Temporary() bool}`},
+		{`package a3; func _(x interface{}) { switch x.(type) { case int, string: case nil: default: } }`, `int: interface{} -> int; string: interface{} -> string`},
+		{`package a4; type T struct{}; func (*T) Error() string; func _(x error) { switch y := x.(type) { case *T: _ = y } }`, `*T: error -> *a4.T`},
+	}
+
+	for _, test := range tests {
+		info := Info{Assertions: make(map[ast.Expr]struct{ From, To Type })}
+		name := mustTypecheck(t, "AssertionsInfo", test.src, &info)
+
+		var list []string
+		for e, a := range info.Assertions {
+			list = append(list, fmt.Sprintf("%s: %s -> %s", ExprString(e), a.From, a.To))
+		}
+		sort.Strings(list)
+		if got := strings.Join(list, "; "); got != test.assertions {
+			t.Errorf("package %s: got %q; want %q", name, got, test.assertions)
+		}
+	}
+}
+
+func TestUnusedResultsInfo(t *testing.T) {
+	var tests = []struct {
+		src    string
+		unused string // sorted list of "call unused-results" pairs
+	}{
+		{`package u0; func f() int; func _() { f() }`, `f() [true]`},
+		{`package u1; func f() (int, error); func _() { f(); (f()) }`, `f() [true true]; f() [true true]`},
+		{`package u2; func f() (int, error); func _() { go f(); defer f() }`, `f() [true true]; f() [true true]`},
+		{`package u3; func f() (int, error); func _() { x, _ := f(); _ = x }`, `f() [false true]`},
+		{`package u4; func f() (int, error); func _() { var x int; x, _ = f(); _, _ = f(); _ = x }`, `f() [false true]; f() [true true]`},
+		{`package u5; func f() (int, error); var _, err = f()`, `f() [true false]`},
+		{`package u6; func f() int; func g() error; func _() { x, _ := f(), g(); _ = x }`, `g() [true]`},
+		{`package u7; func f() (int, error); func _() { x, err := f(); _, _ = x, err }`, ``},
+		{`package u8; func f(); func _() { f(); go f() }`, ``},
+		{`package u9; var s []int; func _() { _ = len(s); _ = int(0); _ = error(nil); copy(s, s) }`, `copy(s, s) [true]`},
+	}
+
+	for _, test := range tests {
+		info := Info{UnusedResults: make(map[*ast.CallExpr][]bool)}
+		name := mustTypecheck(t, "UnusedResultsInfo", test.src, &info)
+
+		var list []string
+		for call, unused := range info.UnusedResults {
+			list = append(list, fmt.Sprintf("%s %v", ExprString(call), unused))
+		}
+		sort.Strings(list)
+		if got := strings.Join(list, "; "); got != test.unused {
+			t.Errorf("package %s: got %q; want %q", name, got, test.unused)
+		}
+	}
+}
+
+func TestTypeOfObjectOf(t *testing.T) {
+	const src = `
+package p
+type T struct{ f int }
+var x T
+var y = x.f + len(z)
+var z []int
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{
+		Types: make(map[ast.Expr]TypeAndValue),
+		Defs:  make(map[*ast.Ident]Object),
+		Uses:  make(map[*ast.Ident]Object),
+	}
+	if _, err := new(Config).Check("p", fset, []*ast.File{f}, &info); err != nil {
+		t.Fatal(err)
+	}
+
+	// collect identifiers in source order
+	var ids []*ast.Ident
+	ast.Inspect(f, func(n ast.Node) bool {
+		if id, _ := n.(*ast.Ident); id != nil {
+			ids = append(ids, id)
+		}
+		return true
+	})
+
+	var got []string
+	var empty Info // no maps
+	for _, id := range ids {
+		if obj := empty.ObjectOf(id); obj != nil {
+			t.Errorf("%s: got object %s without info", id.Name, obj)
+		}
+		if typ := empty.TypeOf(id); typ != nil {
+			t.Errorf("%s: got type %s without info", id.Name, typ)
+		}
+		obj := info.ObjectOf(id)
+		typ := info.TypeOf(id)
+		if obj == nil {
+			got = append(got, fmt.Sprintf("%s: <nil> %v", id.Name, typ))
+			continue
+		}
+		got = append(got, fmt.Sprintf("%s: %s %s", id.Name, obj.Name(), typ))
+	}
+	want := []string{
+		"p: <nil> <nil>", // package name
+		"T: T p.T",
+		"f: f int",
+		"int: int int",
+		"x: x p.T",
+		"T: T p.T",
+		"y: y int",
+		"x: x p.T",
+		"f: f int",
+		"len: len func([]int) int",
+		"z: z []int",
+		"z: z []int",
+		"int: int int",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+
+	// TypeOf also works for non-identifier expressions
+	e := f.Decls[2].(*ast.GenDecl).Specs[0].(*ast.ValueSpec).Values[0]
+	if got, want := fmt.Sprint(info.TypeOf(e)), "int"; got != want {
+		t.Errorf("%s: got type %s; want %s", ExprString(e), got, want)
+	}
+}
+
+func TestRepresentable(t *testing.T) {
+	for _, test := range []struct {
+		lit  string
+		tok  token.Token
+		kind BasicKind
+		want bool
+	}{
+		{"127", token.INT, Int8, true},
+		{"128", token.INT, Int8, false},
+		{"-1", token.INT, Uint, false},
+		{"255", token.INT, Uint8, true},
+		{"256", token.INT, Uint8, false},
+		{"9223372036854775807", token.INT, Int, true},
+		{"9223372036854775808", token.INT, Int, false},
+		{"18446744073709551615", token.INT, Uint64, true},
+		{"18446744073709551616", token.INT, Uint64, false},
+		{"18446744073709551616", token.INT, Float32, true},
+		{"1.5", token.FLOAT, Int, false},
+		{"1e39", token.FLOAT, Float32, false},
+		{"1e39", token.FLOAT, Float64, true},
+		{"1e-2000", token.FLOAT, Float64, true}, // rounds to 0
+		{"1e2000", token.FLOAT, Float64, false},
+		{"1e2000", token.FLOAT, UntypedFloat, true},
+		{"1e39", token.FLOAT, Complex64, false},
+		{"1e39", token.FLOAT, Complex128, true},
+		{"2i", token.IMAG, Complex64, true},
+		{"2i", token.IMAG, Float64, false},
+		{"1e39i", token.IMAG, Complex64, false},
+		{`"foo"`, token.STRING, String, true},
+		{`"foo"`, token.STRING, Int, false},
+	} {
+		x := exact.MakeFromLiteral(test.lit, test.tok)
+		if got := Representable(x, Typ[test.kind]); got != test.want {
+			t.Errorf("Representable(%s, %s) = %v; want %v", test.lit, Typ[test.kind], got, test.want)
+		}
+	}
+
+	// constant negation
+	x := exact.UnaryOp(token.SUB, exact.MakeFromLiteral("128", token.INT), 0)
+	if !Representable(x, Typ[Int8]) {
+		t.Errorf("Representable(%s, int8) = false; want true", x)
+	}
+
+	// named and non-basic types
+	byteType := NewNamed(NewTypeName(token.NoPos, nil, "B", nil), Typ[Byte], nil)
+	for _, test := range []struct {
+		lit  string
+		typ  Type
+		want bool
+	}{
+		{"255", byteType, true},
+		{"300", byteType, false},
+		{"0", NewPointer(Typ[Int]), false},
+		{"0", NewSlice(Typ[Byte]), false},
+		{"0", new(Interface), false},
+	} {
+		x := exact.MakeFromLiteral(test.lit, token.INT)
+		if got := Representable(x, test.typ); got != test.want {
+			t.Errorf("Representable(%s, %s) = %v; want %v", test.lit, test.typ, got, test.want)
+		}
+	}
+}
+
 func predString(tv TypeAndValue) string {
 	var buf bytes.Buffer
 	pred := func(b bool, s string) {
@@ -308,7 +613,7 @@ func TestPredicatesInfo(t *testing.T) {
 
 		// values
 		{`package v0; var (a, b int; _ = a + b)`, `a + b`, `value`},
-		{`package v1; var _ = &[]int{1}`, `([]int literal)`, `value`},
+		{`package v1; var _ = &[]int{1}`, `[]int{1}`, `value`},
 		{`package v2; var _ = func(){}`, `(func() literal)`, `value`},
 		{`package v4; func f() { _ = f }`, `f`, `value`},
 		{`package v3; var _ *int = nil`, `nil`, `value, nil`},
@@ -488,6 +793,161 @@ func TestScopesInfo(t *testing.T) {
 	}
 }
 
+func TestImplicitsInfo(t *testing.T) {
+	const src = `
+package p
+type T struct{}
+type I interface{ m() }
+func _(x interface{}) {
+	switch y := x.(type) {
+	case int:
+		_ = y
+	case *T, I:
+		_ = y
+	case I:
+	case nil:
+	default:
+	}
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{Implicits: make(map[ast.Node]Object)}
+	conf := Config{Error: func(error) {}} // ignore duplicate case error
+	conf.Check("p", fset, []*ast.File{f}, &info)
+
+	// In clauses with a case listing exactly one type, the variable has
+	// that type; otherwise it has the type of the switch expression.
+	var got []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		if clause, _ := n.(*ast.CaseClause); clause != nil {
+			obj := info.Implicits[clause]
+			if obj == nil {
+				t.Errorf("%s: no implicit object", fset.Position(clause.Pos()))
+				return true
+			}
+			got = append(got, fmt.Sprintf("%s %s", obj.Name(), obj.Type()))
+		}
+		return true
+	})
+	want := []string{"y int", "y interface{}", "y p.I", "y interface{}", "y interface{}"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestPkgNamesInfo(t *testing.T) {
+	fset := token.NewFileSet()
+	conf := Config{
+		Packages: make(map[string]*Package),
+		Import: func(imports map[string]*Package, path string) (*Package, error) {
+			return imports[path], nil
+		},
+	}
+	check := func(path, src string, info *Info) *Package {
+		f, err := parser.ParseFile(fset, path, src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg, err := conf.Check(path, fset, []*ast.File{f}, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf.Packages[path] = pkg
+		return pkg
+	}
+
+	check("lib/math", `package math; const Pi = 3.14; func Sqrt(float64) float64`, nil)
+	check("lib/strings", `package strings; func Repeat(string, int) string`, nil)
+
+	const src = `
+package p
+import (
+	m "lib/math"
+	"lib/math"
+	. "lib/strings"
+)
+var _ = m.Pi + math.Sqrt(2)
+var _ = Repeat("x", 2)
+var Pi = 3
+var _ = Pi
+`
+	info := Info{PkgNames: make(map[*ast.Ident]*PkgName)}
+	check("p", src, &info)
+
+	var got []string
+	for id, pkgName := range info.PkgNames {
+		got = append(got, fmt.Sprintf("%s: %s (%s)", fset.Position(id.Pos()), pkgName.Name(), pkgName.Imported().Path()))
+	}
+	sort.Strings(got)
+	want := []string{
+		"p:8:11: m (lib/math)",
+		"p:8:21: math (lib/math)",
+		"p:9:9: . (lib/strings)",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestDotImportsInfo(t *testing.T) {
+	fset := token.NewFileSet()
+	conf := Config{
+		Packages: make(map[string]*Package),
+		Import: func(imports map[string]*Package, path string) (*Package, error) {
+			return imports[path], nil
+		},
+	}
+	check := func(path string, info *Info, sources ...string) *Package {
+		var files []*ast.File
+		for i, src := range sources {
+			f, err := parser.ParseFile(fset, fmt.Sprintf("%s%d", path, i), src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files = append(files, f)
+		}
+		pkg, err := conf.Check(path, fset, files, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf.Packages[path] = pkg
+		return pkg
+	}
+
+	check("lib/math", nil, `package math; const Pi = 3.14; type Float float64`)
+	check("lib/strings", nil, `package strings; func Repeat(string, int) string`)
+
+	info := Info{
+		Uses:       make(map[*ast.Ident]Object),
+		DotImports: make(map[*ast.Ident]*Package),
+	}
+	check("p", &info,
+		`package p; import (. "lib/math"; . "lib/strings"); var _ Float = Pi; var _ = Repeat`,
+		`package p; import "lib/math"; var Pi2 = 2 * math.Pi`,
+	)
+
+	var got []string
+	for id, pkg := range info.DotImports {
+		if info.Uses[id].Pkg() != pkg {
+			t.Errorf("%s: got package %s; want %s", id.Name, pkg, info.Uses[id].Pkg())
+		}
+		got = append(got, fmt.Sprintf("%s: %s (%s)", fset.Position(id.Pos()), id.Name, pkg.Path()))
+	}
+	sort.Strings(got)
+	want := []string{
+		"p0:1:58: Float (lib/math)",
+		"p0:1:66: Pi (lib/math)",
+		"p0:1:78: Repeat (lib/strings)",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
 func TestInitOrderInfo(t *testing.T) {
 	var tests = []struct {
 		src   string
@@ -568,7 +1028,7 @@ func TestInitOrderInfo(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		info := Info{}
+		info := Info{Defs: make(map[*ast.Ident]Object)}
 		name := mustTypecheck(t, "InitOrderInfo", test.src, &info)
 
 		// number of initializers must match
@@ -579,13 +1039,36 @@ func TestInitOrderInfo(t *testing.T) {
 
 		// initializers must match
 		for i, want := range test.inits {
-			got := info.InitOrder[i].String()
+			init := info.InitOrder[i]
+			got := init.String()
 			if got != want {
 				t.Errorf("package %s, init %d: got %s; want %s", name, i, got, want)
 				continue
 			}
+
+			// Lhs variables must be the declared objects
+			for _, lhs := range init.Lhs {
+				if !definesObj(info.Defs, lhs) {
+					t.Errorf("package %s, init %d: %s is not a declared variable", name, i, lhs)
+				}
+			}
+
+			// Rhs must be the initialization expression
+			if got, want := ExprString(init.Rhs), want[strings.Index(want, " = ")+3:]; got != want {
+				t.Errorf("package %s, init %d: got Rhs %s; want %s", name, i, got, want)
+			}
+		}
+	}
+}
+
+// definesObj reports whether obj is defined by an identifier in defs.
+func definesObj(defs map[*ast.Ident]Object, obj Object) bool {
+	for _, def := range defs {
+		if def == obj {
+			return true
 		}
 	}
+	return false
 }
 
 func TestMultiFileInitOrder(t *testing.T) {
@@ -601,6 +1084,10 @@ func TestMultiFileInitOrder(t *testing.T) {
 	fileA := mustParse(`package main; var a = 1`)
 	fileB := mustParse(`package main; var b = 2`)
 
+	// independent and dependent initializers across files
+	fileC := mustParse(`package main; var c1 = d2; var c2 = 3; var c3 = f()`)
+	fileD := mustParse(`package main; var d1 = c3; var d2 = 4; func f() int { return c2 }`)
+
 	// The initialization order must not depend on the parse
 	// order of the files, only on the presentation order to
 	// the type-checker.
@@ -610,6 +1097,8 @@ func TestMultiFileInitOrder(t *testing.T) {
 	}{
 		{[]*ast.File{fileA, fileB}, "[a = 1 b = 2]"},
 		{[]*ast.File{fileB, fileA}, "[b = 2 a = 1]"},
+		{[]*ast.File{fileC, fileD}, "[c2 = 3 c3 = f() d1 = c3 d2 = 4 c1 = d2]"},
+		{[]*ast.File{fileD, fileC}, "[d2 = 4 c1 = d2 c2 = 3 c3 = f() d1 = c3]"},
 	} {
 		var info Info
 		if _, err := new(Config).Check("main", fset, test.files, &info); err != nil {
@@ -621,13 +1110,223 @@ func TestMultiFileInitOrder(t *testing.T) {
 	}
 }
 
+func TestNamedMethodOrder(t *testing.T) {
+	fset := token.NewFileSet()
+	mustParse := func(src string) *ast.File {
+		f, err := parser.ParseFile(fset, "p", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	fileA := mustParse(`package p; func (T) c() {}; type T int; func (*T) a() {}`)
+	fileB := mustParse(`package p; func (T) b() {}; func (T) _() {}; func (*T) d() {}`)
+
+	// Methods must appear in declaration order (file order,
+	// then source order), independent of their names.
+	for _, test := range []struct {
+		files []*ast.File
+		want  string
+	}{
+		{[]*ast.File{fileA, fileB}, "[c a b d]"},
+		{[]*ast.File{fileB, fileA}, "[b d c a]"},
+	} {
+		pkg, err := new(Config).Check("p", fset, test.files, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		T := pkg.Scope().Lookup("T").Type().(*Named)
+		var names []string
+		for i := 0; i < T.NumMethods(); i++ {
+			names = append(names, T.Method(i).Name())
+		}
+		if got := fmt.Sprint(names); got != test.want {
+			t.Fatalf("got %s; want %s", got, test.want)
+		}
+	}
+}
+
+func TestConfigUniverse(t *testing.T) {
+	// extra predeclared objects
+	ext := NewScope(nil, "extension")
+	intParam := NewTuple(NewParam(token.NoPos, nil, "x", Typ[Int]))
+	ext.Insert(NewFunc(token.NoPos, nil, "emit", NewSignature(nil, nil, intParam, nil, false)))
+	ext.Insert(NewVar(token.NoPos, nil, "env", Typ[String]))
+	ext.Insert(NewVar(token.NoPos, nil, "len", Typ[Bool])) // shadowed by predeclared len
+
+	const src = `
+package p
+func _() {
+	emit(len(env))
+}
+func _() {
+	env := 0
+	emit(env)
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := Config{Universe: ext}
+	info := Info{Uses: make(map[*ast.Ident]Object)}
+	if _, err := conf.Check("p", fset, []*ast.File{f}, &info); err != nil {
+		t.Fatal(err)
+	}
+
+	// we must find the extension objects and local declarations
+	var uses []string
+	for id, obj := range info.Uses {
+		if id.Name == "len" || id.Name == "env" {
+			uses = append(uses, fmt.Sprintf("%s: %s", fset.Position(id.Pos()), obj))
+		}
+	}
+	sort.Strings(uses)
+	want := []string{
+		"p:4:11: var env string",
+		"p:4:7: builtin len",
+		"p:8:7: var env int",
+	}
+	if got := fmt.Sprint(uses); got != fmt.Sprint(want) {
+		t.Errorf("got %s; want %s", got, want)
+	}
+
+	// without the extension scope, emit is not declared
+	if _, err := new(Config).Check("p", fset, []*ast.File{f}, nil); err == nil {
+		t.Errorf("expected undeclared name error")
+	}
+
+	// the Universe scope must not have been modified
+	if obj := Universe.Lookup("emit"); obj != nil {
+		t.Errorf("Universe contains %s", obj)
+	}
+}
+
+func TestObjectIds(t *testing.T) {
+	const src = `
+package p
+import u "unsafe"
+const C, c = u.Sizeof(0), 1
+type T struct{ F, f int }
+var V, v int
+func F() {}
+func (T) M() {}
+func (T) m() {}
+`
+	info := Info{Defs: make(map[*ast.Ident]Object)}
+	mustTypecheck(t, "p", src, &info)
+
+	// expected ids, by object name
+	want := map[string]string{
+		"u": "p.u",
+		"C": "C",
+		"c": "p.c",
+		"T": "T",
+		"F": "F",
+		"f": "p.f",
+		"V": "V",
+		"v": "p.v",
+		"M": "M",
+		"m": "p.m",
+	}
+
+	for id, obj := range info.Defs {
+		if obj == nil {
+			continue // package name
+		}
+		if got, want := obj.Exported(), ast.IsExported(id.Name); got != want {
+			t.Errorf("%s: Exported() = %v; want %v", obj, got, want)
+		}
+		if got := obj.Id(); got != want[id.Name] {
+			t.Errorf("%s: Id() = %s; want %s", obj, got, want[id.Name])
+		}
+		if got, want := obj.Id(), Id(obj.Pkg(), obj.Name()); got != want {
+			t.Errorf("%s: Id() = %s; want Id(pkg, name) = %s", obj, got, want)
+		}
+	}
+}
+
+func TestReportShadowing(t *testing.T) {
+	const src = `
+package p
+import len "unsafe"
+type error int
+func _(new int) (true bool) {
+	var _, nil = 0, len.Sizeof(new)
+	_ = nil
+	for cap := range []int{} {
+		_ = cap
+	}
+	switch iota := interface{}(0).(type) {
+	case int, string:
+		_ = iota
+	case bool:
+	}
+	_ = func() { var copy, x int; _, _ = copy, x }
+	return
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, report := range []bool{false, true} {
+		var got []string
+		conf := Config{
+			ReportShadowing: report,
+			Error: func(err error) {
+				e := err.(Error)
+				if !e.Soft {
+					t.Errorf("unexpected hard error: %s", e)
+				}
+				if e.Severity != SeverityWarning {
+					t.Errorf("got severity %s; want warning: %s", e.Severity, e)
+				}
+				got = append(got, fmt.Sprintf("%d: %s", fset.Position(e.Pos).Line, e.Msg))
+			},
+		}
+		conf.Check("p", fset, []*ast.File{f}, nil) // errors reported via conf.Error
+
+		var want []string
+		if report {
+			want = []string{
+				"3: declaration of len shadows predeclared identifier",
+				"4: declaration of error shadows predeclared identifier",
+				"5: declaration of new shadows predeclared identifier",
+				"5: declaration of true shadows predeclared identifier",
+				"6: declaration of nil shadows predeclared identifier",
+				"8: declaration of cap shadows predeclared identifier",
+				"11: declaration of iota shadows predeclared identifier",
+				"16: declaration of copy shadows predeclared identifier",
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("ReportShadowing = %v: got %q; want %q", report, got, want)
+		}
+	}
+}
+
 func TestFiles(t *testing.T) {
 	var sources = []string{
 		"package p; type T struct{}; func (T) m1() {}",
 		"package p; func (T) m2() {}; var x interface{ m1(); m2() } = T{}",
-		"package p; func (T) m3() {}; var y interface{ m1(); m2(); m3() } = T{}",
+		"// Package p.\npackage p; func (T) m3() /* m3 */ {}; var y interface{ m1(); m2(); m3() } = T{}",
+		"package p; import _ \"unsafe\"; func (T) m4() {}",
 		"package p",
 	}
+	// files may be parsed with different modes
+	var modes = []parser.Mode{
+		0,
+		parser.AllErrors | parser.DeclarationErrors,
+		parser.ParseComments,
+		parser.ImportsOnly | parser.ParseComments,
+		parser.PackageClauseOnly,
+	}
 
 	var conf Config
 	fset := token.NewFileSet()
@@ -637,7 +1336,7 @@ func TestFiles(t *testing.T) {
 
 	for i, src := range sources {
 		filename := fmt.Sprintf("sources%d", i)
-		f, err := parser.ParseFile(fset, filename, src, 0)
+		f, err := parser.ParseFile(fset, filename, src, modes[i])
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -646,6 +1345,12 @@ func TestFiles(t *testing.T) {
 		}
 	}
 
+	// the method m4 was not parsed (ImportsOnly)
+	T := pkg.Scope().Lookup("T").Type().(*Named)
+	if got, want := T.NumMethods(), 3; got != want {
+		t.Errorf("T has %d methods, want %d", got, want)
+	}
+
 	// check InitOrder is [x y]
 	var vars []string
 	for _, init := range info.InitOrder {
@@ -781,6 +1486,18 @@ func main() {
 		"(*B).f": {"method expr (*main.B) f(*main.B, int)", "->[0]"},
 	}
 
+	wantIndirections := map[string]string{
+		"A{}.B":    "[false]",
+		"new(A).B": "[true]",
+		"A{}.b":    "[false true]",
+		"new(A).b": "[true true]",
+		"A{}.c":    "[false false]",
+		"new(A).c": "[true false]",
+		"new(A).h": "[true false]",
+		"(*A).f":   "[true true]",
+		"(*B).f":   "[true]",
+	}
+
 	makePkg("lib", libSrc)
 	makePkg("main", mainSrc)
 
@@ -805,10 +1522,27 @@ func main() {
 		}
 		delete(wantOut, syntax)
 
-		// We must explicitly assert properties of the
-		// Signature's receiver since it doesn't participate
-		// in Identical() or String().
-		sig, _ := sel.Type().(*Signature)
+		indirections := sel.Indirections()
+		if len(indirections) != len(sel.Index()) {
+			t.Errorf("%s: got %d indirections for index %v", syntax, len(indirections), sel.Index())
+		}
+		indirect := false
+		for _, ind := range indirections {
+			indirect = indirect || ind
+		}
+		if indirect != sel.Indirect() {
+			t.Errorf("%s: got indirections %v; want Indirect() = %v", syntax, indirections, sel.Indirect())
+		}
+		if want, ok := wantIndirections[syntax]; ok {
+			if got := fmt.Sprint(indirections); got != want {
+				t.Errorf("%s: got indirections %s; want %s", syntax, got, want)
+			}
+		}
+
+		// We must explicitly assert properties of the
+		// Signature's receiver since it doesn't participate
+		// in Identical() or String().
+		sig, _ := sel.Type().(*Signature)
 		if sel.Kind() == MethodVal {
 			got := sig.Recv().Type()
 			want := sel.Recv()
@@ -934,3 +1668,3588 @@ func sameSlice(a, b []int) bool {
 	}
 	return true
 }
+
+func TestCompleteInterface(t *testing.T) {
+	pkg := NewPackage("p", "p")
+	sig := func(params ...Type) *Signature {
+		var vars []*Var
+		for _, typ := range params {
+			vars = append(vars, NewParam(token.NoPos, pkg, "", typ))
+		}
+		return NewSignature(nil, nil, NewTuple(vars...), nil, false)
+	}
+	method := func(name string, sig *Signature) *Func {
+		return NewFunc(token.NoPos, pkg, name, sig)
+	}
+	named := func(name string, underlying Type) *Named {
+		return NewNamed(NewTypeName(token.NoPos, pkg, name, nil), underlying, nil)
+	}
+
+	// A and B both embed R; C declares m with a different signature than A
+	R := named("R", NewInterface([]*Func{method("r", sig())}, nil))
+	A := named("A", NewInterface([]*Func{method("m", sig(Typ[Int]))}, []*Named{R}))
+	B := named("B", NewInterface([]*Func{method("m", sig(Typ[Int]))}, []*Named{R}))
+	C := named("C", NewInterface([]*Func{method("m", sig(Typ[String]))}, nil))
+
+	// lazy is like NewInterface but the underlying types of the embedded
+	// types are set only after the interface is created, so that the
+	// interface is not completed by NewInterface.
+	lazy := func(methods []*Func, embeddeds ...*Named) *Interface {
+		var list []*Named
+		for _, e := range embeddeds {
+			list = append(list, named(e.Obj().Name(), nil))
+		}
+		iface := NewInterface(methods, list)
+		for _, e := range embeddeds {
+			for _, l := range list {
+				if l.Obj().Name() == e.Obj().Name() {
+					l.SetUnderlying(e.Underlying())
+				}
+			}
+		}
+		return iface
+	}
+
+	for _, test := range []struct {
+		iface   *Interface
+		methods string // method names of the completed interface
+		err     string // expected error, if any
+	}{
+		{NewInterface(nil, []*Named{A, B}), "[m r]", ""},
+		{NewInterface([]*Func{method("r", sig())}, []*Named{R}), "[r]", ""},
+		{lazy(nil, A, C), "", "duplicate method m with different signatures func(int) and func(string)"},
+		{lazy([]*Func{method("m", sig())}, A), "", "duplicate method m with different signatures func() and func(int)"},
+		{NewInterface(nil, []*Named{named("T", Typ[Int])}), "", "interface contains embedded non-interface p.T"},
+	} {
+		err := CompleteInterface(test.iface)
+		if err != nil {
+			if err.Error() != test.err {
+				t.Errorf("%s: got error %q; want %q", test.iface, err, test.err)
+			}
+			continue
+		}
+		if test.err != "" {
+			t.Errorf("%s: got no error; want %q", test.iface, test.err)
+			continue
+		}
+		var names []string
+		for i := 0; i < test.iface.NumMethods(); i++ {
+			names = append(names, test.iface.Method(i).Name())
+		}
+		if got := fmt.Sprint(names); got != test.methods {
+			t.Errorf("%s: got methods %s; want %s", test.iface, got, test.methods)
+		}
+	}
+
+	// Complete panics for methods with the same name but different signatures.
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Complete did not panic for conflicting methods")
+			}
+		}()
+		lazy(nil, A, C).Complete()
+	}()
+
+	// NewInterface panics for (fully defined) embedded interfaces
+	// with methods of the same name but different signatures.
+	func() {
+		defer func() {
+			if got, want := fmt.Sprint(recover()), "types.NewInterface: duplicate method m with different signatures func(int) and func(string)"; got != want {
+				t.Errorf("NewInterface: got panic %q; want %q", got, want)
+			}
+		}()
+		NewInterface(nil, []*Named{A, C})
+	}()
+}
+
+func TestNewInterfaceRoundTrip(t *testing.T) {
+	const src = `
+package p
+type R interface{ r() }
+type I interface {
+	R
+	String() string
+	Len() int
+}
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	R := pkg.Scope().Lookup("R").Type().(*Named)
+	I := pkg.Scope().Lookup("I").Type().Underlying().(*Interface)
+
+	// build the equivalent of I, with methods in non-sorted order
+	results := func(typ Type) *Tuple { return NewTuple(NewVar(token.NoPos, pkg, "", typ)) }
+	iface := NewInterface([]*Func{
+		NewFunc(token.NoPos, pkg, "String", NewSignature(nil, nil, nil, results(Typ[String]), false)),
+		NewFunc(token.NoPos, pkg, "Len", NewSignature(nil, nil, nil, results(Typ[Int]), false)),
+	}, []*Named{R})
+
+	// the interface must be usable without calling Complete
+	if !Identical(iface, I) {
+		t.Errorf("got %s; want %s", iface, I)
+	}
+	if got, want := NewMethodSet(iface).String(), NewMethodSet(I).String(); got != want {
+		t.Errorf("got method set %s; want %s", got, want)
+	}
+	for i := 0; i < I.NumMethods(); i++ {
+		if got, want := iface.Method(i).Id(), I.Method(i).Id(); got != want {
+			t.Errorf("method %d: got %s; want %s", i, got, want)
+		}
+	}
+	for i := 0; i < I.NumExplicitMethods(); i++ {
+		if got, want := iface.ExplicitMethod(i).Id(), I.ExplicitMethod(i).Id(); got != want {
+			t.Errorf("explicit method %d: got %s; want %s", i, got, want)
+		}
+	}
+
+	// An interface embedding a type that is not yet defined
+	// must be completed explicitly.
+	N := NewNamed(NewTypeName(token.NoPos, pkg, "N", nil), nil, nil)
+	lazy := NewInterface(nil, []*Named{N})
+	N.SetUnderlying(R.Underlying())
+	if got, want := lazy.Complete().NumMethods(), 1; got != want {
+		t.Errorf("got %d methods; want %d", got, want)
+	}
+}
+
+func TestBasicInfo(t *testing.T) {
+	for _, test := range []struct {
+		kind BasicKind
+		want BasicInfo
+	}{
+		{Bool, IsBoolean},
+		{Int, IsInteger},
+		{Int8, IsInteger},
+		{Uint, IsInteger | IsUnsigned},
+		{Uintptr, IsInteger | IsUnsigned},
+		{Float32, IsFloat},
+		{Complex128, IsComplex},
+		{String, IsString},
+		{UnsafePointer, 0},
+		{UntypedBool, IsBoolean | IsUntyped},
+		{UntypedInt, IsInteger | IsUntyped},
+		{UntypedRune, IsInteger | IsUntyped},
+		{UntypedFloat, IsFloat | IsUntyped},
+		{UntypedComplex, IsComplex | IsUntyped},
+		{UntypedString, IsString | IsUntyped},
+		{UntypedNil, IsUntyped},
+	} {
+		if got := Typ[test.kind].Info(); got != test.want {
+			t.Errorf("%s: got info %b; want %b", Typ[test.kind], got, test.want)
+		}
+	}
+
+	// composite properties
+	for _, test := range []struct {
+		typ                         *Basic
+		ordered, numeric, constType bool
+	}{
+		{Typ[Bool], false, false, true},
+		{Typ[Int16], true, true, true},
+		{Typ[Float64], true, true, true},
+		{Typ[Complex64], false, true, true},
+		{Typ[String], true, false, true},
+		{Typ[UnsafePointer], false, false, false},
+		{UniverseByte, true, true, true},
+		{UniverseRune, true, true, true},
+	} {
+		info := test.typ.Info()
+		if got := info&IsOrdered != 0; got != test.ordered {
+			t.Errorf("%s: got ordered = %v; want %v", test.typ, got, test.ordered)
+		}
+		if got := info&IsNumeric != 0; got != test.numeric {
+			t.Errorf("%s: got numeric = %v; want %v", test.typ, got, test.numeric)
+		}
+		if got := info&IsConstType != 0; got != test.constType {
+			t.Errorf("%s: got const type = %v; want %v", test.typ, got, test.constType)
+		}
+	}
+}
+
+func TestLazyImport(t *testing.T) {
+	// newQ returns a package q whose members are resolved on demand;
+	// the names of the resolved members are appended to *resolved.
+	newQ := func(resolved *[]string) *Package {
+		q := NewPackage("q", "q")
+		q.Scope().SetResolver(func(name string) Object {
+			*resolved = append(*resolved, name)
+			switch name {
+			case "A":
+				return NewConst(token.NoPos, q, name, Typ[Int], exact.MakeInt64(1))
+			case "B":
+				return NewVar(token.NoPos, q, name, Typ[String])
+			}
+			return nil
+		}, []string{"A", "B"})
+		q.MarkComplete()
+		return q
+	}
+
+	for _, test := range []struct {
+		src      string
+		resolved string // names passed to the resolver, in order
+		err      string // expected error, if any
+	}{
+		{`package p; import "q"; const _ = q.A`, `[A]`, ``},
+		{`package p; import "q"; var _ = q.A + q.A`, `[A]`, ``},
+		{`package p; import "q"; var _ = q.C`, `[C]`, `C not declared by package q`},
+		{`package p; import . "q"; var _ = B`, `[A B]`, ``},
+	} {
+		f, err := parser.ParseFile(fset, "p.go", test.src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var resolved []string
+		conf := Config{
+			Import: func(imports map[string]*Package, path string) (*Package, error) {
+				if path != "q" {
+					return nil, fmt.Errorf("no package %q", path)
+				}
+				return newQ(&resolved), nil
+			},
+		}
+		var errs []string
+		conf.Error = func(err error) { errs = append(errs, err.(Error).Msg) }
+		conf.Check("p", fset, []*ast.File{f}, nil)
+
+		if got := fmt.Sprint(resolved); got != test.resolved {
+			t.Errorf("%s: resolved %s; want %s", test.src, got, test.resolved)
+		}
+		if got := strings.Join(errs, "; "); got != test.err {
+			t.Errorf("%s: got error %q; want %q", test.src, got, test.err)
+		}
+	}
+}
+
+func TestNamedConstruction(t *testing.T) {
+	// type List struct { next *List }
+	// func (*List) Next() *List
+	pkg := NewPackage("p", "p")
+	obj := NewTypeName(token.NoPos, pkg, "List", nil)
+	List := NewNamed(obj, nil, nil)
+	if obj.Type() != List {
+		t.Fatalf("got type %s for type name; want %s", obj.Type(), List)
+	}
+
+	// Queries before SetUnderlying must not panic.
+	if List.Underlying() != nil {
+		t.Errorf("got underlying type %s; want nil", List.Underlying())
+	}
+	if !Identical(List, List) || Identical(List, Typ[Int]) {
+		t.Errorf("incomplete named type identical to wrong types")
+	}
+	if AssignableTo(List, Typ[Int]) || ConvertibleTo(List, Typ[Int]) {
+		t.Errorf("incomplete named type assignable or convertible to int")
+	}
+	if got := NewMethodSet(List).Len(); got != 0 {
+		t.Errorf("got %d methods for incomplete named type; want 0", got)
+	}
+
+	ptr := NewPointer(List)
+	List.SetUnderlying(NewStruct([]*Var{NewField(token.NoPos, pkg, "next", ptr, false)}, nil))
+	recv := NewVar(token.NoPos, pkg, "l", ptr)
+	results := NewTuple(NewVar(token.NoPos, pkg, "", ptr))
+	List.AddMethod(NewFunc(token.NoPos, pkg, "Next", NewSignature(nil, recv, nil, results, false)))
+
+	if got, want := NewMethodSet(List).Len(), 0; got != want {
+		t.Errorf("got %d methods for List; want %d", got, want)
+	}
+	if got, want := NewMethodSet(ptr).String(), "MethodSet {\n\tmethod (*p.List) Next() *p.List\n}\n"; got != want {
+		t.Errorf("got method set %s; want %s", got, want)
+	}
+	if obj, _, _ := LookupFieldOrMethod(List, false, pkg, "next"); obj == nil {
+		t.Errorf("field next not found")
+	}
+
+	// The underlying type may be set again only to the same type.
+	List.SetUnderlying(List.Underlying())
+	defer func() {
+		if recover() == nil {
+			t.Errorf("resetting the underlying type didn't panic")
+		}
+	}()
+	List.SetUnderlying(NewStruct(nil, nil))
+}
+
+func TestVariadicElem(t *testing.T) {
+	const src = `package p
+func f(int)
+func g(string, ...int)
+func h(...[]interface{})
+var _ = append([]byte(nil), "foo"...)
+`
+	info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+	pkg, err := pkgFor("p", src, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name string
+		elem string // element type, or "" if not variadic
+	}{
+		{"f", ""},
+		{"g", "int"},
+		{"h", "[]interface{}"},
+	} {
+		sig := pkg.Scope().Lookup(test.name).Type().(*Signature)
+		elem, ok := sig.VariadicElem()
+		if ok != sig.Variadic() {
+			t.Errorf("%s: got variadic = %v; want %v", test.name, ok, sig.Variadic())
+		}
+		var got string
+		if ok {
+			got = elem.String()
+		}
+		if got != test.elem {
+			t.Errorf("%s: got element type %q; want %q", test.name, got, test.elem)
+		}
+	}
+
+	// append([]byte, string...)
+	found := false
+	for e, tv := range info.Types {
+		if id, _ := e.(*ast.Ident); id != nil && id.Name == "append" {
+			elem, ok := tv.Type.(*Signature).VariadicElem()
+			if !ok || elem != UniverseByte {
+				t.Errorf("append: got element type %v, %v; want byte, true", elem, ok)
+			}
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("no signature recorded for append")
+	}
+}
+
+func TestErrorList(t *testing.T) {
+	const src = `package p
+var _ = x
+var _ int = "foo"
+var _ = y
+`
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var list ErrorList
+	if err := list.Err(); err != nil {
+		t.Fatalf("empty list: got error %v; want nil", err)
+	}
+
+	conf := Config{Error: list.Add}
+	conf.Check("p", fset, []*ast.File{f}, nil)
+	n := len(list)
+	// add errors out of order, and duplicates
+	for i := n - 1; i >= 0; i-- {
+		list.Add(list[i])
+	}
+	list.Add(fmt.Errorf("no position"))
+
+	err = list.Err()
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	var got []string
+	for _, err := range list {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		"no position",
+		"p.go:2:9: undeclared name: x",
+		`p.go:3:13: cannot convert "foo" (untyped string constant) to int`,
+		"p.go:4:9: undeclared name: y",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got errors %q; want %q", got, want)
+	}
+	if got, want := err.Error(), "no position (and 3 more errors)"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestComparableReason(t *testing.T) {
+	const src = `package p
+type (
+	S struct{ a int; b []int }
+	T struct{ s [2]S }
+	U struct{ p *U; c chan U }
+	F func()
+)
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := func(name string) Type { return pkg.Scope().Lookup(name).Type() }
+
+	for _, test := range []struct {
+		typ     Type
+		because string // "" if comparable
+	}{
+		{Typ[Int], ""},
+		{Typ[UntypedNil], "untyped nil"},
+		{lookup("S"), "[]int"},
+		{lookup("T"), "[]int"},
+		{lookup("U"), ""},
+		{lookup("F"), "p.F"},
+		{NewArray(lookup("F"), 3), "p.F"},
+		{NewMap(Typ[Int], Typ[Int]), "map[int]int"},
+		{NewPointer(lookup("S")), ""},
+	} {
+		ok, because := ComparableReason(test.typ)
+		var got string
+		if because != nil {
+			got = because.String()
+		}
+		if ok != (test.because == "") || got != test.because {
+			t.Errorf("%s: got %v, %q; want %q", test.typ, ok, got, test.because)
+		}
+		if ok != Comparable(test.typ) {
+			t.Errorf("%s: ComparableReason and Comparable disagree", test.typ)
+		}
+	}
+
+	// A (programmatically constructed, invalid) recursive
+	// struct type must not cause an infinite recursion.
+	R := NewNamed(NewTypeName(token.NoPos, pkg, "R", nil), nil, nil)
+	R.SetUnderlying(NewStruct([]*Var{NewField(token.NoPos, pkg, "r", R, false)}, nil))
+	if ok, because := ComparableReason(R); !ok {
+		t.Errorf("%s: got not comparable because of %s", R, because)
+	}
+}
+
+func TestCheckWithTests(t *testing.T) {
+	sources := []string{
+		`package p_test; import "p"; var _ = p.F(p.Internal)`,
+		`package p; var x int; func F(int) int { return x }`,
+		`package p; var Internal = x // internal test file`,
+		`package p_test; import "p"; type T struct{ p.S }`,
+		`package p; type S struct{}`,
+	}
+	var files []*ast.File
+	for i, src := range sources {
+		f, err := parser.ParseFile(fset, fmt.Sprintf("f%d.go", i), src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	info := Info{Defs: make(map[*ast.Ident]Object)}
+	var conf Config
+	pkg, xtest, err := conf.CheckWithTests("p", fset, files, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := pkg.Scope().Names(), []string{"F", "Internal", "S", "x"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("package p: got %v; want %v", got, want)
+	}
+	if xtest == nil {
+		t.Fatal("no external test package")
+	}
+	if got, want := xtest.Path(), "p_test"; got != want {
+		t.Errorf("got external test package path %s; want %s", got, want)
+	}
+	if got, want := xtest.Scope().Names(), []string{"T"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("package p_test: got %v; want %v", got, want)
+	}
+	if imports := xtest.Imports(); len(imports) != 1 || imports[0] != pkg {
+		t.Errorf("package p_test: got imports %v; want [%v]", imports, pkg)
+	}
+
+	// info covers both packages
+	found := false
+	for id, obj := range info.Defs {
+		if id.Name == "T" {
+			found = obj.Pkg() == xtest
+		}
+	}
+	if !found {
+		t.Errorf("no definition of p_test.T recorded")
+	}
+
+	// without external test files
+	_, xtest, err = conf.CheckWithTests("p", fset, files[1:3], nil)
+	if err != nil || xtest != nil {
+		t.Errorf("got external test package %v, %v; want nil, nil", xtest, err)
+	}
+}
+
+func TestBoundSignature(t *testing.T) {
+	const src = `package p
+type T struct{ f func(int) }
+func (T) m(x int) string
+func (*T) n(string, ...int)
+type I interface{ m(int) string }
+var (
+	t T
+	p *T
+	i I
+)
+var (
+	_ = t.m
+	_ = p.m
+	_ = p.n
+	_ = T.m
+	_ = (*T).m
+	_ = (*T).n
+	_ = I.m
+	_ = i.m
+	_ = t.f
+)
+`
+	info := Info{Selections: make(map[*ast.SelectorExpr]*Selection)}
+	mustTypecheck(t, "BoundSignature", src, &info)
+
+	want := map[string]string{
+		"t.m":    "func(x int) string",
+		"p.m":    "func(x int) string",
+		"p.n":    "func(string, ...int)",
+		"T.m":    "func(p.T, x int) string",
+		"(*T).m": "func(*p.T, x int) string",
+		"(*T).n": "func(*p.T, string, ...int)",
+		"I.m":    "func(p.I, int) string",
+		"i.m":    "func(int) string",
+		"t.f":    "<nil>",
+	}
+	for e, sel := range info.Selections {
+		syntax := ExprString(e)
+		sig := sel.BoundSignature()
+		got := "<nil>"
+		if sig != nil {
+			got = sig.String()
+			if sig.Recv() != nil {
+				t.Errorf("%s: bound signature has receiver %s", syntax, sig.Recv())
+			}
+		}
+		if got != want[syntax] {
+			t.Errorf("%s: got %s; want %s", syntax, got, want[syntax])
+		}
+		delete(want, syntax)
+	}
+	for syntax := range want {
+		t.Errorf("no selection found for %s", syntax)
+	}
+}
+
+func TestLenientUnsafe(t *testing.T) {
+	const src = `package p
+import "unsafe"
+var f float64
+var p = unsafe.Pointer(f)
+var i = int(unsafe.Pointer(&f))
+`
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, lenient := range []bool{false, true} {
+		var errs []Error
+		conf := Config{
+			LenientUnsafe: lenient,
+			Error:         func(err error) { errs = append(errs, err.(Error)) },
+		}
+		pkg, _ := conf.Check("p", fset, []*ast.File{f}, nil)
+
+		if len(errs) != 2 {
+			t.Errorf("lenient = %v: got %d errors; want 2", lenient, len(errs))
+		}
+		for _, err := range errs {
+			if err.Soft != lenient {
+				t.Errorf("lenient = %v: got soft = %v for %s", lenient, err.Soft, err)
+			}
+		}
+		if lenient {
+			for name, want := range map[string]string{"p": "unsafe.Pointer", "i": "int"} {
+				if got := pkg.Scope().Lookup(name).Type().String(); got != want {
+					t.Errorf("lenient: got type %s for %s; want %s", got, name, want)
+				}
+			}
+		}
+	}
+}
+
+func TestValidSize(t *testing.T) {
+	const src = `package p
+type (
+	L struct{ next *L; list []L; m map[int]L; c chan L; f func(L) L; i interface{ m(L) } }
+	A struct{ b *B; a [2]*A }
+	B struct{ a A }
+	D struct{ x, y E } // D uses E twice
+	E struct{ z [3]F }
+	F int
+)
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range pkg.Scope().Names() {
+		if T := pkg.Scope().Lookup(name).Type(); !ValidSize(T) {
+			t.Errorf("%s: got invalid size", T)
+		}
+	}
+
+	// Construct mutually recursive structs
+	//	type X struct{ y [1]Y }
+	//	type Y struct{ x X }
+	X := NewNamed(NewTypeName(token.NoPos, pkg, "X", nil), nil, nil)
+	Y := NewNamed(NewTypeName(token.NoPos, pkg, "Y", nil), nil, nil)
+	X.SetUnderlying(NewStruct([]*Var{NewField(token.NoPos, pkg, "y", NewArray(Y, 1), false)}, nil))
+	Y.SetUnderlying(NewStruct([]*Var{NewField(token.NoPos, pkg, "x", X, false)}, nil))
+	for _, T := range []Type{X, Y, NewStruct([]*Var{NewField(token.NoPos, pkg, "X", X, true)}, nil)} {
+		if ValidSize(T) {
+			t.Errorf("%s: got valid size", T)
+		}
+	}
+	if !ValidSize(NewSlice(X)) || !ValidSize(NewPointer(Y)) {
+		t.Errorf("indirections of invalid types must have valid size")
+	}
+}
+
+func TestUndeclaredInfo(t *testing.T) {
+	const src = `package p
+var x = undef1 + 1
+var y = x * 2
+var _ undef2
+func f() int { return undef3(y) }
+`
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []string
+	conf := Config{Error: func(err error) { errs = append(errs, err.(Error).Msg) }}
+	info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+	conf.Check("p", fset, []*ast.File{f}, &info)
+
+	// no follow-up errors
+	want := []string{"undeclared name: undef1", "undeclared name: undef2", "undeclared name: undef3"}
+	if fmt.Sprint(errs) != fmt.Sprint(want) {
+		t.Errorf("got errors %q; want %q", errs, want)
+	}
+
+	undeclared := make(map[string]bool)
+	for e, tv := range info.Types {
+		if id, _ := e.(*ast.Ident); id != nil && strings.HasPrefix(id.Name, "undef") {
+			if tv.Type != Typ[Invalid] || tv.IsValue() || tv.IsType() != (id.Name == "undef2") || tv.IsVoid() {
+				t.Errorf("%s: got %v", id.Name, tv)
+			}
+			undeclared[id.Name] = true
+		}
+		// the rest of the file is still checked
+		if ExprString(e) == "y" && tv.Type == nil {
+			t.Errorf("y: no type recorded")
+		}
+	}
+	if len(undeclared) != 3 {
+		t.Errorf("got types for %v; want all 3 undeclared identifiers", undeclared)
+	}
+}
+
+func TestTyp(t *testing.T) {
+	for kind, typ := range Typ {
+		if typ.Kind() != BasicKind(kind) {
+			t.Errorf("Typ[%d] has kind %d", kind, typ.Kind())
+		}
+		if kind == int(UnsafePointer) {
+			if obj := Unsafe.Scope().Lookup("Pointer"); obj.Type() != typ {
+				t.Errorf("unsafe.Pointer: got %s; want %s", obj.Type(), typ)
+			}
+			continue
+		}
+		// typed basic types are predeclared in the universe
+		obj := Universe.Lookup(typ.Name())
+		if got := obj != nil && obj.Type() == typ; got != (typ.Info()&IsUntyped == 0 && kind != int(Invalid)) {
+			t.Errorf("Typ[%d] (%s): predeclared = %v", kind, typ, got)
+		}
+	}
+
+	// byte and rune are aliases
+	for _, test := range []struct {
+		name string
+		typ  *Basic
+	}{
+		{"byte", UniverseByte},
+		{"rune", UniverseRune},
+	} {
+		if got := Universe.Lookup(test.name).Type(); got != test.typ || !Identical(got, Typ[test.typ.Kind()]) {
+			t.Errorf("%s: got %s", test.name, got)
+		}
+	}
+}
+
+func TestNewSignatureVariadic(t *testing.T) {
+	pkg := NewPackage("p", "p")
+	param := func(typ Type) *Var { return NewParam(token.NoPos, pkg, "", typ) }
+
+	sig := NewSignature(nil, nil, NewTuple(param(Typ[String]), param(NewSlice(Typ[Int]))), nil, true)
+	if got, want := sig.String(), "func(string, ...int)"; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+	fn := NewFunc(token.NoPos, pkg, "f", sig)
+	if got, want := fn.String(), "func p.f(string, ...int)"; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+
+	named := NewNamed(NewTypeName(token.NoPos, pkg, "S", nil), NewSlice(Typ[Int]), nil)
+	for _, test := range []struct {
+		params *Tuple
+		panic  string
+	}{
+		{nil, "types.NewSignature: variadic function must have at least one parameter"},
+		{NewTuple(param(Typ[Int])), "types.NewSignature: variadic parameter must be of unnamed slice type, not int"},
+		{NewTuple(param(named)), "types.NewSignature: variadic parameter must be of unnamed slice type, not p.S"},
+	} {
+		func() {
+			defer func() {
+				if got := fmt.Sprint(recover()); got != test.panic {
+					t.Errorf("%v: got panic %q; want %q", test.params, got, test.panic)
+				}
+			}()
+			NewSignature(nil, nil, test.params, nil, true)
+		}()
+	}
+}
+
+func TestUnusedInfo(t *testing.T) {
+	const src = `package p
+
+func f(x, _ int) (r int) {
+	var a, b, _ int
+	c, d := 1, 2
+	_ = b
+	{
+		e := d
+	}
+	switch y := interface{}(x).(type) {
+	case int:
+	case string, bool:
+	}
+	switch z := interface{}(x).(type) {
+	case int:
+		_ = z
+	case string:
+	}
+	g := func() { h := 0 }
+	return
+}
+`
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var info Info
+	conf := Config{Error: func(error) {}}
+	conf.Check("p", fset, []*ast.File{f}, &info)
+
+	var got []string
+	for _, v := range info.Unused {
+		got = append(got, fmt.Sprintf("%s %s %s", fset.Position(v.Pos()), v.Name(), v.Type()))
+	}
+	want := []string{
+		"p.go:10:9 y int",
+		"p.go:10:9 y interface{}",
+		"p.go:19:16 h int",
+		"p.go:4:6 a int",
+		"p.go:5:2 c int",
+		"p.go:19:2 g func()",
+		"p.go:8:3 e int",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestSelectorCandidates(t *testing.T) {
+	const libSrc = `package lib
+
+type T struct {
+	Exported int
+	hidden   int
+}
+
+func (T) M()   {}
+func (T) m()   {}
+func (*T) PM() {}
+
+const C = 0
+var v int
+func F() {}
+`
+	const src = `package p
+
+import "lib"
+
+type I interface { IM() }
+
+type E struct { e int; x int }
+
+func (*E) EM() {}
+
+type S struct {
+	lib.T
+	*E
+	I
+	x, y int
+}
+
+func (S) SM() {}
+
+var s S
+var ps *S
+
+func f() S { return s }
+`
+	lib, err := pkgFor("lib.go", libSrc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := Config{Import: func(imports map[string]*Package, path string) (*Package, error) {
+		imports[path] = lib
+		return lib, nil
+	}}
+	info := Info{Scopes: make(map[ast.Node]*Scope)}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileScope := info.Scopes[f]
+
+	for _, test := range []struct {
+		src, want string
+	}{
+		{"lib._", "[C F T]"},
+		{"s._", "[E EM Exported I IM M PM SM T e x y]"}, // S.x shadows E.x
+		{"ps._", "[E EM Exported I IM M PM SM T e x y]"},
+		{"f()._", "[E EM Exported I IM M SM T e x y]"}, // not addressable
+		{"S._", "[EM IM M SM]"},
+		{"I._", "[IM]"},
+	} {
+		x, err := parser.ParseExpr(test.src)
+		if err != nil {
+			t.Errorf("%s: %s", test.src, err)
+			continue
+		}
+		list, err := SelectorCandidates(fset, x.(*ast.SelectorExpr), pkg, fileScope)
+		if err != nil {
+			t.Errorf("%s: %s", test.src, err)
+			continue
+		}
+		var names []string
+		for _, obj := range list {
+			names = append(names, obj.Name())
+		}
+		if got := fmt.Sprint(names); got != test.want {
+			t.Errorf("%s: got %s; want %s", test.src, got, test.want)
+		}
+	}
+
+	// an incomplete selector expression, as produced by the parser for "s."
+	x, _ := parser.ParseExpr("s.")
+	if sel, _ := x.(*ast.SelectorExpr); sel != nil {
+		if list, err := SelectorCandidates(fset, sel, pkg, fileScope); err != nil || len(list) == 0 {
+			t.Errorf("s.: got %v, %v; want candidates", list, err)
+		}
+	}
+}
+
+func TestTrace(t *testing.T) {
+	const src = `package p
+
+type T struct{ f int }
+
+const c = 1 + 2
+
+var x = T{}.f
+`
+	f, err := parser.ParseFile(fset, "trace.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	conf := Config{Trace: &buf}
+	if _, err := conf.Check("p", fset, []*ast.File{f}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	trace := buf.String()
+	for _, want := range []string{
+		"trace.go:5:7:\t-- declaring c",
+		"=> 1 + 2 (untyped int constant 3)",
+		"trace.go:7:13:\t.  .  selector f on T: field f int (index [0], indirect false)",
+		"=> var x int",
+	} {
+		if !strings.Contains(trace, want) {
+			t.Errorf("trace does not contain %q:\n%s", want, trace)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	for _, test := range []struct {
+		typ  Type
+		want string
+	}{
+		{Typ[UntypedBool], "bool"},
+		{Typ[UntypedInt], "int"},
+		{Typ[UntypedRune], "rune"},
+		{Typ[UntypedFloat], "float64"},
+		{Typ[UntypedComplex], "complex128"},
+		{Typ[UntypedString], "string"},
+		{Typ[UntypedNil], "untyped nil"},
+		{Typ[Int8], "int8"},
+		{NewSlice(Typ[UntypedInt]), "[]untyped int"},
+	} {
+		if got := Default(test.typ).String(); got != test.want {
+			t.Errorf("Default(%s) = %s; want %s", test.typ, got, test.want)
+		}
+	}
+	if !Identical(Default(Typ[UntypedRune]), Typ[Int32]) {
+		t.Errorf("Default(untyped rune) is not identical to int32")
+	}
+
+	// default types of untyped constant expressions
+	info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+	mustTypecheck(t, "Default", "package p; const c = 'a' + 1.0", &info)
+	for e, tv := range info.Types {
+		if _, ok := e.(*ast.BinaryExpr); ok {
+			if got := Default(tv.Type).String(); got != "float64" {
+				t.Errorf("Default(%s) = %s; want float64", tv.Type, got)
+			}
+		}
+	}
+}
+
+func TestArrayImplicit(t *testing.T) {
+	const src = `package p
+
+var a = [...]int{1, 2, 3}
+var b = [3]int{1, 2, 3}
+var c = [...]string{5: "x"}
+`
+	info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+	pkg, err := pkgFor("implicit.go", src, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name     string
+		len      int64
+		implicit bool
+	}{
+		{"a", 3, true},
+		{"b", 3, false},
+		{"c", 6, true},
+	} {
+		typ := pkg.Scope().Lookup(test.name).Type().(*Array)
+		if typ.Len() != test.len || typ.Implicit() != test.implicit {
+			t.Errorf("%s: got len %d, implicit %v; want %d, %v", test.name, typ.Len(), typ.Implicit(), test.len, test.implicit)
+		}
+	}
+	if !Identical(pkg.Scope().Lookup("a").Type(), pkg.Scope().Lookup("b").Type()) {
+		t.Errorf("[...]int{1, 2, 3} and [3]int{1, 2, 3} have different types")
+	}
+}
+
+func TestPromotedFrom(t *testing.T) {
+	const src = `package p
+
+type A struct {
+	*B
+	C
+	a int
+}
+
+type B struct{ b int }
+
+func (B) f() {}
+
+type C struct {
+	D
+	I
+}
+
+type D struct{ d int }
+
+type I interface{ m() }
+
+var a A
+var _, _, _, _, _, _ = a.a, a.b, a.f, a.d, a.m, a.C
+`
+	info := Info{Selections: make(map[*ast.SelectorExpr]*Selection)}
+	mustTypecheck(t, "PromotedFrom", src, &info)
+
+	want := map[string]string{
+		"a.a": "<nil>",
+		"a.b": "p.B",
+		"a.f": "p.B",
+		"a.d": "p.D",
+		"a.m": "p.I",
+		"a.C": "<nil>",
+	}
+	for e, sel := range info.Selections {
+		x := ExprString(e)
+		got := "<nil>"
+		if named := sel.PromotedFrom(); named != nil {
+			got = named.String()
+		}
+		if got != want[x] {
+			t.Errorf("%s: got %s; want %s", x, got, want[x])
+		}
+		delete(want, x)
+	}
+	for x := range want {
+		t.Errorf("%s: selection not found", x)
+	}
+}
+
+func TestCompositeLitTypesInfo(t *testing.T) {
+	const src = `package p
+
+type T struct {
+	a int
+	b []byte
+}
+
+var _ = T{1, nil}
+var _ = T{b: []byte{'x', 2: 'y'}}
+var _ = []T{{a: 1}, {2, nil}}
+var _ = [...]*T{{}, &T{}}
+var _ = map[string]T{"x": {}, "y": T{a: 3}}
+`
+	info := Info{CompositeLitTypes: make(map[ast.Expr]Type)}
+	mustTypecheck(t, "CompositeLitTypes", src, &info)
+
+	var got []string
+	for e, typ := range info.CompositeLitTypes {
+		// the file is the first (and only) file of its file set, with base 1
+		got = append(got, fmt.Sprintf("%s: %s", src[e.Pos()-1:e.End()-1], typ))
+	}
+	sort.Strings(got)
+	want := []string{
+		"\"x\": string",
+		"\"y\": string",
+		"&T{}: *p.T",
+		"'x': byte",
+		"'y': byte",
+		"1: int",
+		"1: int",
+		"2: int",
+		"3: int",
+		"T{a: 3}: p.T",
+		"[]byte{'x', 2: 'y'}: []byte",
+		"nil: []byte",
+		"nil: []byte",
+		"{2, nil}: p.T",
+		"{a: 1}: p.T",
+		"{}: *p.T",
+		"{}: p.T",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestImportPlaceholder(t *testing.T) {
+	const src = `package p
+
+import "q"
+
+var x q.T = q.F()
+var y int = "foo"
+`
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var errors []string
+	conf := Config{
+		Import: func(imports map[string]*Package, path string) (*Package, error) {
+			return NewPackage(path, "q"), fmt.Errorf("import cycle not allowed")
+		},
+		Error: func(err error) { errors = append(errors, err.(Error).Msg) },
+	}
+	pkg, _ := conf.Check("p", fset, []*ast.File{f}, nil)
+
+	want := []string{
+		"could not import q (import cycle not allowed)",
+		`cannot convert "foo" (untyped string constant) to int`,
+	}
+	if fmt.Sprint(errors) != fmt.Sprint(want) {
+		t.Errorf("got errors %q; want %q", errors, want)
+	}
+	if len(pkg.Imports()) != 0 {
+		t.Errorf("got imports %v; want none", pkg.Imports())
+	}
+
+	// A package returned together with an import error is not
+	// modified: a later successful import of it reports errors
+	// for undeclared members as usual.
+	q := NewPackage("q", "q")
+	fail := true
+	conf = Config{
+		Import: func(imports map[string]*Package, path string) (*Package, error) {
+			if fail {
+				return q, fmt.Errorf("incomplete package")
+			}
+			return q, nil
+		},
+		Error: func(error) {},
+	}
+	conf.Check("p", fset, []*ast.File{f}, nil)
+	fail = false
+	conf.Error = nil
+	if _, err := conf.Check("p", fset, []*ast.File{f}, nil); err == nil || !strings.Contains(err.Error(), "T not declared by package q") {
+		t.Errorf("got error %v; want T not declared by package q", err)
+	}
+}
+
+func TestChanDir(t *testing.T) {
+	named := NewNamed(NewTypeName(token.NoPos, nil, "C", nil), NewChan(RecvOnly, Typ[Int]), nil)
+	for _, test := range []struct {
+		typ              Type
+		elem             string
+		dir              ChanDir
+		ok               bool
+		canSend, canRecv bool
+	}{
+		{NewChan(SendRecv, Typ[Int]), "int", SendRecv, true, true, true},
+		{NewChan(SendOnly, Typ[String]), "string", SendOnly, true, true, false},
+		{NewChan(RecvOnly, Typ[Bool]), "bool", RecvOnly, true, false, true},
+		{named, "int", RecvOnly, true, false, true},
+		{NewSlice(Typ[Int]), "<nil>", SendRecv, false, false, false},
+	} {
+		elem, dir, ok := ChanElemFor(test.typ)
+		if fmt.Sprint(elem) != test.elem || dir != test.dir || ok != test.ok {
+			t.Errorf("ChanElemFor(%s) = %v, %v, %v; want %s, %v, %v", test.typ, elem, dir, ok, test.elem, test.dir, test.ok)
+		}
+		if c, _ := test.typ.Underlying().(*Chan); c != nil {
+			if c.CanSend() != test.canSend || c.CanRecv() != test.canRecv {
+				t.Errorf("%s: CanSend() = %v, CanRecv() = %v; want %v, %v", test.typ, c.CanSend(), c.CanRecv(), test.canSend, test.canRecv)
+			}
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	const src = `package p
+
+var a = Injected + 1
+
+func f(Local int) {
+	_ = Local
+	var _ Type = Undefined
+}
+`
+	f, err := parser.ParseFile(fset, "resolve.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls, errors []string
+	injected := map[string]Object{
+		"Injected": NewConst(token.NoPos, nil, "Injected", Typ[UntypedInt], exact.MakeInt64(41)),
+		"Type":     NewTypeName(token.NoPos, nil, "Type", Typ[Float64]),
+		"Local":    NewVar(token.NoPos, nil, "Local", Typ[String]), // never used: Local is a parameter
+	}
+	conf := Config{
+		Resolve: func(name string, scope *Scope) Object {
+			calls = append(calls, name)
+			if scope == nil || scope == Universe {
+				t.Errorf("%s: got scope %v; want package or function scope", name, scope)
+			}
+			return injected[name]
+		},
+		Error: func(err error) { errors = append(errors, err.(Error).Msg) },
+	}
+	info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+	pkg, _ := conf.Check("p", fset, []*ast.File{f}, &info)
+
+	if got, want := fmt.Sprint(calls), "[Injected Type Undefined]"; got != want {
+		t.Errorf("got calls %s; want %s", got, want)
+	}
+	if got, want := fmt.Sprint(errors), "[undeclared name: Undefined]"; got != want {
+		t.Errorf("got errors %s; want %s", got, want)
+	}
+	a := pkg.Scope().Lookup("a").(*Var)
+	if got := a.Type().String(); got != "int" {
+		t.Errorf("a has type %s; want int", got)
+	}
+}
+
+func TestFileImportsInfo(t *testing.T) {
+	lib := NewPackage("lib", "lib")
+	lib.Scope().Insert(NewConst(token.NoPos, lib, "C", Typ[Int], exact.MakeInt64(0)))
+	lib.MarkComplete()
+	other := NewPackage("other", "other")
+	other.Scope().Insert(NewConst(token.NoPos, other, "D", Typ[Int], exact.MakeInt64(0)))
+	other.MarkComplete()
+
+	sources := []string{
+		`package p
+
+import (
+	"lib"
+	m "lib"
+	_ "lib"
+	. "other"
+)
+
+var _ = m.C + D
+`,
+		`package p
+
+import (
+	. "lib"
+	"other"
+)
+
+var _ = other.D
+`,
+	}
+	var files []*ast.File
+	for i, src := range sources {
+		f, err := parser.ParseFile(fset, fmt.Sprintf("imports%d.go", i), src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	conf := Config{
+		Import: func(imports map[string]*Package, path string) (*Package, error) {
+			return map[string]*Package{"lib": lib, "other": other}[path], nil
+		},
+		Error: func(error) {},
+	}
+	info := Info{FileImports: make(map[*ast.File]*FileImports)}
+	conf.Check("p", fset, files, &info)
+
+	names := func(list []*PkgName) string {
+		var s []string
+		for _, obj := range list {
+			s = append(s, obj.Name()+" "+obj.Imported().Path())
+		}
+		return "[" + strings.Join(s, ", ") + "]"
+	}
+	for i, want := range []struct{ declared, used string }{
+		{"[lib lib, m lib, _ lib, . other]", "[m lib, . other]"},
+		{"[. lib, other other]", "[other other]"},
+	} {
+		fi := info.FileImports[files[i]]
+		if fi == nil {
+			t.Errorf("file %d: no imports recorded", i)
+			continue
+		}
+		if got := names(fi.Declared); got != want.declared {
+			t.Errorf("file %d: got declared %s; want %s", i, got, want.declared)
+		}
+		if got := names(fi.Used); got != want.used {
+			t.Errorf("file %d: got used %s; want %s", i, got, want.used)
+		}
+	}
+}
+
+func TestIsInterfaceMethod(t *testing.T) {
+	const src = `package p
+
+type I interface {
+	m()
+	J
+}
+
+type J interface{ n() }
+
+type T struct{ J }
+
+func (T) m() {}
+
+func f() {}
+
+var _ interface{ o() }
+`
+	pkg, err := pkgFor("ifacemethod.go", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := pkg.Scope()
+	I := scope.Lookup("I").Type().Underlying().(*Interface)
+	T := scope.Lookup("T").Type().(*Named)
+	lit := NewInterface([]*Func{NewFunc(token.NoPos, pkg, "o", NewSignature(nil, nil, nil, nil, false))}, nil)
+	tm, _, _ := LookupFieldOrMethod(T, false, pkg, "n") // promoted from J
+
+	for _, test := range []struct {
+		obj  *Func
+		want bool
+	}{
+		{I.Method(0), true},   // m
+		{I.Method(1), true},   // n, via embedded J
+		{T.Method(0), false},  // concrete method
+		{tm.(*Func), true},    // interface method promoted to T
+		{lit.Method(0), true}, // method of interface constructed with NewInterface
+		{scope.Lookup("f").(*Func), false},
+	} {
+		if got := test.obj.IsInterfaceMethod(); got != test.want {
+			t.Errorf("%s: IsInterfaceMethod() = %v; want %v", test.obj, got, test.want)
+		}
+		if test.want {
+			if _, ok := test.obj.Type().(*Signature).Recv().Type().Underlying().(*Interface); !ok {
+				t.Errorf("%s: receiver is not of interface type", test.obj)
+			}
+		}
+	}
+}
+
+func TestAssignableToReason(t *testing.T) {
+	const src = `package p
+
+type I interface{ m() }
+type J interface{ m(int) }
+
+type T struct{}
+func (T) m() {}
+
+type P struct{}
+func (*P) m() {}
+
+type A []int
+type B []int
+
+type C chan int
+`
+	pkg, err := pkgFor("assignable.go", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := func(name string) Type { return pkg.Scope().Lookup(name).Type() }
+	I, J, T, P, A, B := lookup("I"), lookup("J"), lookup("T"), lookup("P"), lookup("A"), lookup("B")
+
+	for _, test := range []struct {
+		V, T   Type
+		ok     bool
+		reason string
+	}{
+		{T, I, true, ""},
+		{NewPointer(P), I, true, ""},
+		{A, NewSlice(Typ[Int]), true, ""},
+		{NewChan(SendRecv, Typ[Int]), lookup("C"), true, ""},
+		{Typ[UntypedNil], NewSlice(Typ[Int]), true, ""},
+		{T, J, false, "wrong type for method m"},
+		{P, I, false, "method m has pointer receiver"},
+		{Typ[Int], I, false, "missing method m"},
+		{A, B, false, "p.A and p.B are different named types"},
+		{NewChan(RecvOnly, Typ[Int]), NewChan(SendRecv, Typ[Int]), false, "channel directions differ"},
+		{Typ[UntypedNil], Typ[Int], false, "nil is not a valid value of type int"},
+		{Typ[Int], Typ[String], false, "int and string have different underlying types"},
+	} {
+		ok, reason := AssignableToReason(test.V, test.T)
+		if ok != test.ok || reason != test.reason {
+			t.Errorf("AssignableToReason(%s, %s) = %v, %q; want %v, %q", test.V, test.T, ok, reason, test.ok, test.reason)
+		}
+		if ok != AssignableTo(test.V, test.T) {
+			t.Errorf("AssignableToReason(%s, %s) and AssignableTo disagree", test.V, test.T)
+		}
+	}
+}
+
+func TestExportedOnly(t *testing.T) {
+	const src = `package p
+
+type T struct{ f hidden }
+
+func (T) M() {}
+func (T) m() undefined1
+
+type hidden struct{ x int }
+
+func (hidden) helper() int { return 0 }
+
+func F(h hidden) int { return undefined2 }
+
+var V = mk()
+
+func mk() *hidden { return nil }
+
+func unreachable() undefined3
+
+var w undefined4
+`
+	f, err := parser.ParseFile(fset, "exportedonly.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var errs []string
+	conf := Config{
+		ExportedOnly: true,
+		Error:        func(err error) { errs = append(errs, err.Error()) },
+	}
+	info := Info{InitOrder: []*Initializer{}}
+	pkg, _ := conf.Check("p", fset, []*ast.File{f}, &info)
+
+	// only the error in the unexported method of the exported type T
+	// is reported; bodies and unreachable declarations are ignored
+	if len(errs) != 1 || !strings.Contains(errs[0], "undefined1") {
+		t.Errorf("got errors %v; want only undefined1", errs)
+	}
+
+	for _, test := range []struct {
+		name, typ string
+	}{
+		{"T", "p.T"},
+		{"F", "func(h p.hidden) int"},
+		{"V", "*p.hidden"},
+		{"hidden", "p.hidden"},
+		{"mk", "func() *p.hidden"},
+		{"unreachable", "<nil>"},
+		{"w", "<nil>"},
+	} {
+		obj := pkg.Scope().Lookup(test.name)
+		var got string
+		if obj.Type() == nil {
+			got = "<nil>"
+		} else {
+			got = obj.Type().String()
+		}
+		if got != test.typ {
+			t.Errorf("%s: got type %s; want %s", test.name, got, test.typ)
+		}
+	}
+
+	// methods of reachable unexported types are type-checked
+	if typ := pkg.Scope().Lookup("hidden").Type().(*Named); typ.NumMethods() != 1 {
+		t.Errorf("hidden has %d methods; want 1", typ.NumMethods())
+	}
+
+	if len(info.InitOrder) != 0 {
+		t.Errorf("got InitOrder %v; want none", info.InitOrder)
+	}
+}
+
+func TestScopeChildrenOrder(t *testing.T) {
+	const src = `package p
+
+var x = b() + a()
+
+func a() int { return 0 }
+
+func b() int {
+	if true {}
+	for {}
+}
+
+var y = func() int { return 1 }
+`
+	pkg, err := pkgFor("children.go", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := pkg.Scope().NumChildren(); n != 1 {
+		t.Fatalf("got %d file scopes; want 1", n)
+	}
+	fileScope := pkg.Scope().Child(0)
+
+	// functions a and b are type-checked in the order in which they are
+	// referenced by x; the scopes must nevertheless be in source order
+	var got []string
+	for i := 0; i < fileScope.NumChildren(); i++ {
+		s := fileScope.Child(i)
+		got = append(got, fmt.Sprintf("%d", s.NumChildren()))
+	}
+	// a: 0 nested scopes, b: 2 (if, for), func literal of y: 0
+	if want := "0 2 0"; strings.Join(got, " ") != want {
+		t.Errorf("got children counts %s; want %s", strings.Join(got, " "), want)
+	}
+}
+
+func TestFileScopesOrder(t *testing.T) {
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, src := range []string{
+		"package p; func a() { if true {} }",
+		"package p; func b() { for {} }",
+	} {
+		name := fmt.Sprintf("%c.go", 'a'+len(files))
+		f, err := parser.ParseFile(fset, name, src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+
+	// present the files out of parse order
+	files[0], files[1] = files[1], files[0]
+	var conf Config
+	pkg, err := conf.Check("p", fset, files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the file scopes are in presentation order
+	var got []string
+	for i := 0; i < pkg.Scope().NumChildren(); i++ {
+		s := pkg.Scope().Child(i)
+		got = append(got, s.String()[:strings.Index(s.String(), " scope")])
+	}
+	if want := "[b.go a.go]"; fmt.Sprint(got) != want {
+		t.Errorf("got file scopes %v; want %s", got, want)
+	}
+}
+
+func TestNoInitOrder(t *testing.T) {
+	const src = `package p
+
+var a = b
+var b = f()
+
+func f() int { return a }
+
+var c int = "foo"
+`
+	f, err := parser.ParseFile(fset, "noinitorder.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, noInitOrder := range []bool{false, true} {
+		var errs []string
+		conf := Config{
+			NoInitOrder: noInitOrder,
+			Error:       func(err error) { errs = append(errs, err.Error()) },
+		}
+		info := Info{InitOrder: []*Initializer{}}
+		conf.Check("p", fset, []*ast.File{f}, &info)
+
+		cycle := false
+		for _, err := range errs {
+			if strings.Contains(err, "initialization cycle") {
+				cycle = true
+			}
+		}
+		if cycle == noInitOrder {
+			t.Errorf("NoInitOrder = %v: got cycle error = %v; errors: %v", noInitOrder, cycle, errs)
+		}
+		// the assignment error must be reported in either case
+		if !strings.Contains(strings.Join(errs, "\n"), "cannot convert") {
+			t.Errorf("NoInitOrder = %v: assignment error not reported; errors: %v", noInitOrder, errs)
+		}
+		if got := len(info.InitOrder) != 0; got == noInitOrder {
+			t.Errorf("NoInitOrder = %v: got InitOrder %v", noInitOrder, info.InitOrder)
+		}
+	}
+}
+
+func TestReceiverNamed(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+func (T) m() {}
+func (*T) n() {}
+
+type I interface{ m() }
+var x interface{ m() }
+
+func f() {}
+`
+	pkg, err := pkgFor("receivernamed.go", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	T := pkg.Scope().Lookup("T").Type().(*Named)
+	I := pkg.Scope().Lookup("I").Type().(*Named)
+	x := pkg.Scope().Lookup("x").Type().(*Interface)
+
+	for _, test := range []struct {
+		obj   *Func
+		named *Named
+		isPtr bool
+	}{
+		{T.Method(0), T, false},
+		{T.Method(1), T, true},
+		{I.Underlying().(*Interface).Method(0), I, false},
+		{x.Method(0), nil, false},
+		{pkg.Scope().Lookup("f").(*Func), nil, false},
+	} {
+		named, isPtr := test.obj.ReceiverNamed()
+		if named != test.named || isPtr != test.isPtr {
+			t.Errorf("%s: got (%v, %v); want (%v, %v)", test.obj, named, isPtr, test.named, test.isPtr)
+		}
+	}
+}
+
+func TestShadowed(t *testing.T) {
+	const src = `package p
+
+type A struct{ x, y int }
+func (A) m() {}
+func (A) n() {}
+
+type B struct{ x int }
+func (*B) n() {}
+
+type C struct{ z int }
+
+type D struct{ C }
+
+type I interface{ n() }
+
+type T struct {
+	A
+	*B
+	D
+	C
+	y string
+}
+
+type U struct {
+	I
+	A
+}
+`
+	pkg, err := pkgFor("shadowed.go", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		typ  string
+		want string
+	}{
+		{"A", ""},
+		{"D", ""},
+		// T.n and T.x are ambiguous (A.n, B.n and A.x, B.x), A.y is shadowed
+		// by T.y, and D.C is shadowed by T.C (C.z is the same field either way)
+		{"T", "func (A).n(); func (*B).n(); field x int; field x int; field y int; field C C"},
+		{"U", "func (I).n(); func (A).n()"},
+	} {
+		var got []string
+		for _, obj := range Shadowed(NewPointer(pkg.Scope().Lookup(test.typ).Type())) {
+			got = append(got, ObjectString(pkg, obj))
+		}
+		if s := strings.Join(got, "; "); s != test.want {
+			t.Errorf("Shadowed(%s) = %s; want %s", test.typ, s, test.want)
+		}
+	}
+}
+
+// mapObserver records the results reported to an Observer in an Info.
+type mapObserver struct{ info Info }
+
+func (o *mapObserver) OnType(x ast.Expr, tv TypeAndValue)              { o.info.Types[x] = tv }
+func (o *mapObserver) OnDef(id *ast.Ident, obj Object)                 { o.info.Defs[id] = obj }
+func (o *mapObserver) OnUse(id *ast.Ident, obj Object)                 { o.info.Uses[id] = obj }
+func (o *mapObserver) OnImplicit(node ast.Node, obj Object)            { o.info.Implicits[node] = obj }
+func (o *mapObserver) OnSelection(x *ast.SelectorExpr, sel *Selection) { o.info.Selections[x] = sel }
+func (o *mapObserver) OnScope(node ast.Node, scope *Scope)             { o.info.Scopes[node] = scope }
+
+func newTestInfo() Info {
+	return Info{
+		Types:      make(map[ast.Expr]TypeAndValue),
+		Defs:       make(map[*ast.Ident]Object),
+		Uses:       make(map[*ast.Ident]Object),
+		Implicits:  make(map[ast.Node]Object),
+		Selections: make(map[*ast.SelectorExpr]*Selection),
+		Scopes:     make(map[ast.Node]*Scope),
+	}
+}
+
+func TestObserver(t *testing.T) {
+	const src = `package p
+
+type T struct{ f int }
+func (T) m() {}
+
+var m map[string]int
+var x interface{}
+
+func f(t *T) (int, bool) {
+	t.m()
+	_ = len("foo") + 1.0
+	switch x := x.(type) {
+	case int:
+		_ = x
+	}
+	v, ok := (m["foo"])
+	_, _ = x.(int)
+	return v + t.f, ok
+}
+`
+	f, err := parser.ParseFile(fset, "observer.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// type-check once with the Info maps, and once with an observer only
+	want := newTestInfo()
+	var conf Config
+	if _, err := conf.Check("p", fset, []*ast.File{f}, &want); err != nil {
+		t.Fatal(err)
+	}
+	o := &mapObserver{newTestInfo()}
+	conf.Observer = o
+	if _, err := conf.Check("p", fset, []*ast.File{f}, nil); err != nil {
+		t.Fatal(err)
+	}
+	got := o.info
+
+	if len(got.Types) != len(want.Types) {
+		t.Errorf("got %d types; want %d", len(got.Types), len(want.Types))
+	}
+	for x, tv := range want.Types {
+		gtv := got.Types[x]
+		if gtv.Type == nil || TypeString(nil, gtv.Type) != TypeString(nil, tv.Type) ||
+			gtv.IsValue() != tv.IsValue() || gtv.Addressable() != tv.Addressable() ||
+			gtv.Assignable() != tv.Assignable() || gtv.HasOk() != tv.HasOk() {
+			t.Errorf("%s: got %v; want %v", ExprString(x), gtv, tv)
+		}
+	}
+	for _, test := range []struct {
+		name      string
+		got, want int
+	}{
+		{"Defs", len(got.Defs), len(want.Defs)},
+		{"Uses", len(got.Uses), len(want.Uses)},
+		{"Implicits", len(got.Implicits), len(want.Implicits)},
+		{"Selections", len(got.Selections), len(want.Selections)},
+		{"Scopes", len(got.Scopes), len(want.Scopes)},
+	} {
+		if test.got != test.want {
+			t.Errorf("got %d %s; want %d", test.got, test.name, test.want)
+		}
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	const src = `package p
+
+type I interface{ m(); M() }
+type J interface{ M(int) }
+type E interface{}
+
+type T struct{}
+func (T) m() {}
+func (T) M() {}
+
+type P struct{}
+func (*P) m() {}
+func (*P) M() {}
+`
+	pkg, err := pkgFor("satisfies.go", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := pkgFor("q.go", "package q; type I interface{ m(); M() }", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := func(pkg *Package, name string) Type { return pkg.Scope().Lookup(name).Type() }
+	iface := func(pkg *Package, name string) *Interface { return lookup(pkg, name).Underlying().(*Interface) }
+	T, P := lookup(pkg, "T"), lookup(pkg, "P")
+
+	for _, test := range []struct {
+		typ   Type
+		iface *Interface
+		want  bool
+	}{
+		{T, iface(pkg, "I"), true},
+		{NewPointer(T), iface(pkg, "I"), true},
+		{P, iface(pkg, "I"), false}, // pointer receivers
+		{NewPointer(P), iface(pkg, "I"), true},
+		{T, iface(pkg, "J"), false}, // M has different signature
+		{T, iface(pkg, "E"), true},
+		{Typ[Int], iface(pkg, "E"), true},
+		{Typ[Int], iface(pkg, "I"), false},
+		{T, iface(q, "I"), false}, // q.I.m is a different method
+		{lookup(q, "I"), iface(q, "I"), true},
+	} {
+		mset := NewMethodSet(test.typ)
+		if got := Satisfies(mset, test.iface); got != test.want {
+			t.Errorf("Satisfies(%s, %s) = %v; want %v", test.typ, test.iface, got, test.want)
+		}
+		if got := Implements(test.typ, test.iface); got != test.want {
+			t.Errorf("Implements(%s, %s) = %v; want %v", test.typ, test.iface, got, test.want)
+		}
+	}
+}
+
+func TestChainImporters(t *testing.T) {
+	a := NewPackage("a", "a")
+	b := NewPackage("b", "b")
+	placeholder := NewPackage("c", "c")
+	only := func(pkg *Package) Importer {
+		return func(_ map[string]*Package, path string) (*Package, error) {
+			if path == pkg.Path() {
+				return pkg, nil
+			}
+			return nil, fmt.Errorf("%s not found", path)
+		}
+	}
+	failing := func(_ map[string]*Package, path string) (*Package, error) {
+		return placeholder, fmt.Errorf("cannot import %s", path)
+	}
+
+	imp := ChainImporters(only(a), nil, failing, only(b))
+	for _, test := range []struct {
+		path string
+		pkg  *Package
+		err  string
+	}{
+		{"a", a, ""},
+		{"b", b, ""},
+		{"c", placeholder, "c not found (and 2 more errors)"},
+	} {
+		pkg, err := imp(nil, test.path)
+		var msg string
+		if err != nil {
+			msg = err.Error()
+		}
+		if pkg != test.pkg || msg != test.err {
+			t.Errorf("import %q: got %v, %q; want %v, %q", test.path, pkg, msg, test.pkg, test.err)
+		}
+	}
+
+	if _, err := ChainImporters()(nil, "a"); err == nil {
+		t.Errorf("empty importer chain succeeded")
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	const src = `package p
+
+var i int
+var f float64
+
+const c0 = 1 / 0
+const c1 = 1.5 % 0
+const c2 = (1 + 2i) / 0
+const c3 = 1.0 / (0 * 2)
+var v0 = i / 0
+var v1 = i % (0)
+var v2 = f / 0 // valid: non-constant floating-point division
+
+var _ = c0 + 1 // no follow-on errors
+var _ int = v0
+`
+	f, err := parser.ParseFile(fset, "divzero.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var errs []Error
+	conf := Config{Error: func(err error) { errs = append(errs, err.(Error)) }}
+	conf.Check("p", fset, []*ast.File{f}, nil)
+
+	// c1 is reported as an invalid operation on untyped floats
+	want := []string{"1 / 0", "(1 + 2i) / 0", "1.0 / (0 * 2)", "i / 0", "i % (0)"}
+	var got []string
+	for _, err := range errs {
+		if !strings.Contains(err.Msg, "division by zero") {
+			continue
+		}
+		if !err.Soft {
+			t.Errorf("%s: division by zero is not a soft error", fset.Position(err.Pos))
+		}
+		// the error is reported at the divisor: find the enclosing binary expression
+		ast.Inspect(f, func(n ast.Node) bool {
+			if b, _ := n.(*ast.BinaryExpr); b != nil && b.Y.Pos() == err.Pos {
+				got = append(got, ExprString(b))
+			}
+			return true
+		})
+	}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("got division by zero errors for %v; want %v", got, want)
+	}
+	if n := len(errs) - len(got); n != 1 {
+		t.Errorf("got %d other errors; want 1: %v", n, errs)
+	}
+}
+
+func TestEmbeddedNonInterface(t *testing.T) {
+	const src = `package p
+
+type S struct{}
+
+type I interface {
+	m()
+	S
+}
+`
+	f, err := parser.ParseFile(fset, "embedded.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []Error
+	conf := Config{Error: func(err error) { errs = append(errs, err.(Error)) }}
+	pkg, _ := conf.Check("p", fset, []*ast.File{f}, nil)
+
+	if len(errs) != 1 {
+		t.Fatalf("got errors %v; want 1 error", errs)
+	}
+	if got, want := errs[0].Msg, "interface contains embedded non-interface S"; got != want {
+		t.Errorf("got error %q; want %q", got, want)
+	}
+	if got, want := fset.Position(errs[0].Pos).String(), "embedded.go:7:2"; got != want {
+		t.Errorf("got error at %s; want %s", got, want)
+	}
+
+	// the remaining methods are still collected
+	if n := pkg.Scope().Lookup("I").Type().Underlying().(*Interface).NumMethods(); n != 1 {
+		t.Errorf("got %d methods; want 1", n)
+	}
+
+	// programmatically constructed interfaces
+	S := pkg.Scope().Lookup("S").Type().(*Named)
+	iface := NewInterface(nil, []*Named{S})
+	if err := CompleteInterface(iface); err == nil || err.Error() != "interface contains embedded non-interface p.S" {
+		t.Errorf("CompleteInterface: got error %v", err)
+	}
+	defer func() {
+		if p := recover(); p == nil || !strings.Contains(fmt.Sprint(p), "embedded non-interface p.S") {
+			t.Errorf("Complete: got panic %v", p)
+		}
+	}()
+	iface.Complete()
+}
+
+func TestUnderlyingBase(t *testing.T) {
+	pkg, err := pkgFor("base.go", "package p; type A B; type B C; type C int; type S struct{}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := func(name string) Type { return pkg.Scope().Lookup(name).Type() }
+
+	for _, name := range []string{"A", "B", "C"} {
+		typ := lookup(name)
+		if got := UnderlyingBase(typ); got != Typ[Int] {
+			t.Errorf("UnderlyingBase(%s) = %s; want int", name, got)
+		}
+		// the checker resolves underlying types fully
+		if got := typ.Underlying(); got != Typ[Int] {
+			t.Errorf("%s.Underlying() = %s; want int", name, got)
+		}
+	}
+	if got := UnderlyingBase(NewPointer(lookup("A"))); got.String() != "*p.A" {
+		t.Errorf("UnderlyingBase(*A) = %s; want *p.A", got)
+	}
+	if got := UnderlyingBase(lookup("S")); got.String() != "struct{}" {
+		t.Errorf("UnderlyingBase(S) = %s; want struct{}", got)
+	}
+
+	// incomplete named type
+	T := NewNamed(NewTypeName(token.NoPos, pkg, "T", nil), nil, nil)
+	if got := UnderlyingBase(T); got != nil {
+		t.Errorf("UnderlyingBase(T) = %s; want nil", got)
+	}
+}
+
+func TestPackageTypes(t *testing.T) {
+	const src = `package p
+
+type Z int
+const c = 0
+type (
+	B struct{}
+	A interface{}
+)
+func f() { type local int }
+var v int
+`
+	pkg, err := pkgFor("packagetypes.go", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, typ := range PackageTypes(pkg) {
+		got = append(got, typ.Obj().Name())
+	}
+	if got, want := strings.Join(got, " "), "Z B A"; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+
+	// packages without source order are sorted by name
+	q := NewPackage("q", "q")
+	for _, name := range []string{"Y", "X"} {
+		obj := NewTypeName(token.NoPos, q, name, nil)
+		NewNamed(obj, Typ[Int], nil)
+		q.Scope().Insert(obj)
+	}
+	q.Scope().Insert(pkg.Scope().Lookup("Z")) // type name declared in another package
+	got = nil
+	for _, typ := range PackageTypes(q) {
+		got = append(got, typ.Obj().Name())
+	}
+	if got, want := strings.Join(got, " "), "X Y"; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
+
+func TestTupleTypesInfo(t *testing.T) {
+	const src = `
+package p
+
+func f() (int, string)
+func g(int, string)
+func h()
+
+func _(m map[string]int, c chan int, x interface{}) {
+	g(f())
+	h()
+	select {
+	case v, ok := <-c:
+		_, _ = v, ok
+	}
+	var a, b = (m["foo"])
+	_, _ = a, b
+	_ = x.(int)
+}
+`
+	info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+	mustTypecheck(t, "TupleTypesInfo", src, &info)
+
+	tests := []struct {
+		expr, want string
+		void       bool
+	}{
+		{`f()`, `(int, string)`, false},
+		{`h()`, `()`, true},
+		{`<-c`, `(int, bool)`, false},
+		{`(m["foo"])`, `(int, bool)`, false},
+		{`m["foo"]`, `(int, bool)`, false},
+		{`x.(int)`, `int`, false},
+	}
+	for _, test := range tests {
+		var tv TypeAndValue
+		var found bool
+		for e, v := range info.Types {
+			if ExprString(e) == test.expr {
+				tv, found = v, true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("%s: no type recorded", test.expr)
+			continue
+		}
+		if got := tv.Type.String(); got != test.want {
+			t.Errorf("%s: got type %s; want %s", test.expr, got, test.want)
+		}
+		if _, ok := tv.Type.(*Tuple); ok != strings.HasPrefix(test.want, "(") {
+			t.Errorf("%s: got type %T; want tuple = %v", test.expr, tv.Type, !ok)
+		}
+		if got := tv.IsVoid(); got != test.void {
+			t.Errorf("%s: got IsVoid() = %v; want %v", test.expr, got, test.void)
+		}
+	}
+}
+
+func TestIntSizeOverflow(t *testing.T) {
+	tests := []struct {
+		src      string
+		overflow [2]bool // for 32-bit and 64-bit int, respectively
+	}{
+		{`var _ int = 1<<31 - 1`, [2]bool{false, false}},
+		{`var _ int = 1 << 31`, [2]bool{true, false}},
+		{`var _ int = 1 << 40`, [2]bool{true, false}},
+		{`var _ = 1 << 40`, [2]bool{true, false}},
+		{`var _ int = -1 << 31`, [2]bool{false, false}},
+		{`var _ int = 1 << 63`, [2]bool{true, true}},
+		{`var _ uint = 1<<32 - 1`, [2]bool{false, false}},
+		{`var _ uint = 1 << 32`, [2]bool{true, false}},
+		{`var _ uintptr = 1 << 40`, [2]bool{true, false}},
+		{`const c int = 1 << 30; var _ = c * 4`, [2]bool{true, false}},
+		{`var _ int32 = 1 << 40`, [2]bool{true, true}},
+		{`var _ int64 = 1 << 40`, [2]bool{false, false}},
+	}
+
+	for _, test := range tests {
+		for i, wordSize := range []int64{4, 8} {
+			src := "package p; " + test.src
+			f, err := parser.ParseFile(fset, "p.go", src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var errs []error
+			conf := Config{
+				Sizes: &StdSizes{WordSize: wordSize, MaxAlign: wordSize},
+				Error: func(err error) { errs = append(errs, err) },
+			}
+			conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+			overflow := false
+			for _, err := range errs {
+				if strings.Contains(err.Error(), "overflows") {
+					overflow = true
+				} else {
+					t.Errorf("%s (word size %d): unexpected error: %s", test.src, wordSize, err)
+				}
+			}
+			if overflow != test.overflow[i] {
+				t.Errorf("%s (word size %d): got overflow = %v; want %v", test.src, wordSize, overflow, test.overflow[i])
+			}
+		}
+	}
+}
+
+func TestImplementedInterfaces(t *testing.T) {
+	const src = `package p
+
+type Stringer interface{ String() string }
+type Closer interface{ Close() error }
+type StringCloser interface{ Stringer; Closer }
+type E interface{}
+
+type T struct{}
+func (T) String() string { return "" }
+func (*T) Close() error { return nil }
+`
+	pkg, err := pkgFor("implemented.go", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := pkg.Scope()
+	var ifaces []*Interface
+	names := make(map[*Interface]string)
+	for _, name := range []string{"Stringer", "Closer", "StringCloser", "E"} {
+		iface := scope.Lookup(name).Type().Underlying().(*Interface)
+		ifaces = append(ifaces, iface)
+		names[iface] = name
+	}
+	T := scope.Lookup("T").Type()
+
+	for _, test := range []struct {
+		typ  Type
+		want string
+	}{
+		{T, "Stringer E"},
+		{NewPointer(T), "Stringer Closer StringCloser E"},
+		{Typ[Int], "E"},
+		{scope.Lookup("StringCloser").Type(), "Stringer Closer StringCloser E"},
+	} {
+		var got []string
+		for _, iface := range ImplementedInterfaces(test.typ, ifaces) {
+			got = append(got, names[iface])
+		}
+		if got := strings.Join(got, " "); got != test.want {
+			t.Errorf("ImplementedInterfaces(%s) = %s; want %s", test.typ, got, test.want)
+		}
+	}
+}
+
+func TestCheckVariants(t *testing.T) {
+	parse := func(name, src string) *ast.File {
+		f, err := parser.ParseFile(fset, name, src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	common := []*ast.File{
+		parse("common.go", `package p; import "q"; type T struct{ q.Q; f wordType }; var _ = undeclared`),
+	}
+	variants := map[string][]*ast.File{
+		"amd64": {parse("amd64.go", `package p; type wordType int64; func Extra() {}`)},
+		"386":   {parse("386.go", `package p; type wordType int32`)},
+	}
+
+	q := NewPackage("q", "q")
+	q.Scope().Insert(NewTypeName(token.NoPos, q, "Q", Typ[Int]))
+	q.MarkComplete()
+	imports := 0
+	var errs []string
+	conf := Config{
+		Import: func(m map[string]*Package, path string) (*Package, error) {
+			if pkg := m[path]; pkg != nil {
+				return pkg, nil
+			}
+			imports++
+			m[path] = q
+			return q, nil
+		},
+		Error: func(err error) { errs = append(errs, err.Error()) },
+	}
+	pkgs, err := conf.CheckVariants("p", fset, common, variants)
+	if err == nil {
+		t.Errorf("got no error; want undeclared error")
+	}
+
+	if len(pkgs) != 2 || pkgs["amd64"] == nil || pkgs["386"] == nil {
+		t.Fatalf("got packages %v; want amd64 and 386 variants", pkgs)
+	}
+	if imports != 1 {
+		t.Errorf("package q imported %d times; want 1", imports)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0], "undeclared") {
+		t.Errorf("got errors %q; want a single undeclared error", errs)
+	}
+
+	amd64, x386 := pkgs["amd64"].Scope(), pkgs["386"].Scope()
+	T1, T2 := amd64.Lookup("T"), x386.Lookup("T")
+	if T1 == T2 || Identical(T1.Type(), T2.Type()) {
+		t.Errorf("variants share common object T")
+	}
+	if T1.Pos() != T2.Pos() {
+		t.Errorf("common object T has different positions in variants")
+	}
+	for _, test := range []struct {
+		scope *Scope
+		want  string
+	}{
+		{amd64, "int64"},
+		{x386, "int32"},
+	} {
+		f := test.scope.Lookup("T").Type().Underlying().(*Struct).Field(1)
+		if got := f.Type().Underlying().String(); got != test.want {
+			t.Errorf("T.f has underlying type %s; want %s", got, test.want)
+		}
+	}
+	if amd64.Lookup("Extra") == nil || x386.Lookup("Extra") != nil {
+		t.Errorf("Extra should only be declared in the amd64 variant")
+	}
+}
+
+func TestIsError(t *testing.T) {
+	const src = `package p
+
+type E error
+type N interface{ Error() string }
+type S interface{ String() string }
+type T struct{}
+func (T) Error() string { return "" }
+
+var (
+	err error
+	e E
+	n N
+	s S
+	x T
+	lit interface{ Error() string }
+	other interface{ Error() int }
+)
+`
+	pkg, err := pkgFor("iserror.go", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name              string
+		isError, hasError bool
+	}{
+		{"err", true, true},
+		{"e", false, true},
+		{"n", false, true},
+		{"s", false, false},
+		{"x", false, false},
+		{"lit", false, true},
+		{"other", false, false},
+	} {
+		typ := pkg.Scope().Lookup(test.name).Type()
+		if got := IsError(typ); got != test.isError {
+			t.Errorf("IsError(%s) = %v; want %v", typ, got, test.isError)
+		}
+		if got := HasErrorUnderlying(typ); got != test.hasError {
+			t.Errorf("HasErrorUnderlying(%s) = %v; want %v", typ, got, test.hasError)
+		}
+	}
+}
+
+func TestAllDependencies(t *testing.T) {
+	pkgs := make(map[string]*Package)
+	for _, path := range []string{"a", "b", "c", "d", "e", "p"} {
+		pkgs[path] = NewPackage(path, path)
+	}
+	imports := func(path string, list ...string) {
+		var imps []*Package
+		for _, p := range list {
+			imps = append(imps, pkgs[p])
+		}
+		pkgs[path].SetImports(imps)
+	}
+	imports("p", "c", "a")
+	imports("a", "d", "b")
+	imports("b", "d")
+	imports("c", "e", "b")
+	imports("e", "c") // cycle
+
+	var got []string
+	for _, dep := range AllDependencies(pkgs["p"]) {
+		got = append(got, dep.Path())
+	}
+	if got, want := strings.Join(got, " "), "d b a e c"; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+	if deps := AllDependencies(pkgs["d"]); len(deps) != 0 {
+		t.Errorf("got %v; want no dependencies", deps)
+	}
+
+	// Imports lists packages in order of their first import.
+	f, err := parser.ParseFile(fset, "imports.go", `package p; import (_ "fmt"; _ "unsafe"; _ "errors"; _ "fmt")`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := Config{Import: func(m map[string]*Package, path string) (*Package, error) {
+		if path == "unsafe" {
+			return Unsafe, nil
+		}
+		pkg := m[path]
+		if pkg == nil {
+			pkg = NewPackage(path, path)
+			pkg.MarkComplete()
+			m[path] = pkg
+		}
+		return pkg, nil
+	}}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, imp := range pkg.Imports() {
+		got = append(got, imp.Path())
+	}
+	if got, want := strings.Join(got, " "), "fmt errors"; got != want {
+		t.Errorf("got imports %s; want %s", got, want)
+	}
+}
+
+func TestNamedResultsInfo(t *testing.T) {
+	const src = `package p
+
+func f() (n int, err error) {
+	_ = func() (m, _ string) { return }
+	return
+}
+`
+	f, err := parser.ParseFile(fset, "results.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{
+		Types:  make(map[ast.Expr]TypeAndValue),
+		Defs:   make(map[*ast.Ident]Object),
+		Scopes: make(map[ast.Node]*Scope),
+	}
+	var conf Config
+	if _, err := conf.Check("p", fset, []*ast.File{f}, &info); err != nil {
+		t.Fatal(err)
+	}
+
+	var sigs []*Signature
+	var ftypes []*ast.FuncType
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			sigs = append(sigs, info.Defs[n.Name].Type().(*Signature))
+			ftypes = append(ftypes, n.Type)
+		case *ast.FuncLit:
+			sigs = append(sigs, info.Types[n].Type.(*Signature))
+			ftypes = append(ftypes, n.Type)
+		}
+		return true
+	})
+
+	var got []string
+	for i, sig := range sigs {
+		scope := info.Scopes[ftypes[i]] // function scope
+		j := 0
+		for _, fld := range ftypes[i].Results.List {
+			for _, id := range fld.Names {
+				res := sig.Results().At(j)
+				j++
+				if info.Defs[id] != res {
+					t.Errorf("%s: Defs entry %v is not result %v", id.Name, info.Defs[id], res)
+				}
+				if res.Pos() != id.Pos() {
+					t.Errorf("%s: result has position %s; want %s", id.Name, fset.Position(res.Pos()), fset.Position(id.Pos()))
+				}
+				if id.Name != "_" && scope.Lookup(id.Name) != res {
+					t.Errorf("%s: result not declared in function scope", id.Name)
+				}
+				got = append(got, res.Name())
+			}
+		}
+	}
+	if got, want := strings.Join(got, " "), "n err m _"; got != want {
+		t.Errorf("got results %s; want %s", got, want)
+	}
+}
+
+func TestCheckRename(t *testing.T) {
+	const src = `package p
+
+import "unsafe"
+
+type Stringer interface{ String() string }
+
+type T struct{ a, b int }
+
+func (T) String() string { return "" }
+func (*T) m() {}
+
+var global unsafe.Pointer
+
+func f(x int) int {
+	y := 1
+	{
+		z := y
+		_ = z
+	}
+	return x + y + int(uintptr(global))
+}
+
+func (r *T) n(k int) {
+	h := func(v int) int { return v + k }
+	_ = h(int(uintptr(global)))
+}
+`
+	f, err := parser.ParseFile(fset, "rename.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{
+		Types:  make(map[ast.Expr]TypeAndValue),
+		Defs:   make(map[*ast.Ident]Object),
+		Uses:   make(map[*ast.Ident]Object),
+		Scopes: make(map[ast.Node]*Scope),
+	}
+	var conf Config
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// lookup returns the object declared by the first
+	// identifier with the given name in source order
+	lookup := func(name string) Object {
+		var obj Object
+		ast.Inspect(f, func(n ast.Node) bool {
+			if id, _ := n.(*ast.Ident); id != nil && id.Name == name && obj == nil {
+				obj = info.Defs[id]
+			}
+			return obj == nil
+		})
+		if obj == nil {
+			t.Fatalf("%s not found", name)
+		}
+		return obj
+	}
+
+	for _, test := range []struct {
+		obj, newName string
+		want         string // ";"-separated list of expected error substrings
+	}{
+		{"a", "c", ""},
+		{"a", "a", ""},
+		{"a", "b", "field b int is declared in the same struct"},
+		{"a", "m", "func (*T).m() is declared for p.T"},
+		{"a", "A", "would become exported"},
+		{"a", "1x", "is not a valid identifier"},
+		{"a", "func", "is not a valid identifier"},
+		{"m", "String", "would become exported;func (T).String() string is declared for p.T"},
+		{"String", "Str", "p.T would no longer implement p.Stringer"},
+		{"Stringer", "stringer", "would become unexported"},
+		{"global", "Global", "would become exported"},
+		{"global", "f", "func f(x int) int is declared in the same scope"},
+		{"global", "unsafe", "package unsafe is declared in a file scope;would refer to package unsafe"},
+		{"global", "y", "a reference to var global unsafe.Pointer would refer to var y int"},
+		{"x", "global", "var x int would shadow var global unsafe.Pointer"},
+		{"x", "y", "var y int is declared in the same scope"},
+		{"z", "x", ""},
+		{"z", "y", "var z int would shadow var y int"},
+		{"r", "global", "var r *T would shadow var global unsafe.Pointer"},
+		{"k", "v", "a reference to var k int would refer to var v int"},
+		{"v", "k", "var v int would shadow var k int"},
+		{"v", "global", ""},
+	} {
+		var got []string
+		for _, err := range CheckRename(pkg, &info, lookup(test.obj), test.newName) {
+			got = append(got, err.Error())
+		}
+		var want []string
+		if test.want != "" {
+			want = strings.Split(test.want, ";")
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s -> %s: got errors %q; want %d errors", test.obj, test.newName, got, len(want))
+			continue
+		}
+		for i, w := range want {
+			if !strings.Contains(got[i], w) {
+				t.Errorf("%s -> %s: got error %q; want %q", test.obj, test.newName, got[i], w)
+			}
+		}
+	}
+
+	// renaming an interface method breaks its implementations
+	iface := pkg.Scope().Lookup("Stringer").Type().Underlying().(*Interface)
+	errs := CheckRename(pkg, &info, iface.Method(0), "Str")
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "p.T would no longer implement p.Stringer") {
+		t.Errorf("got errors %v; want p.T would no longer implement p.Stringer", errs)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	const src = `package p; func _(len int) { var x int }`
+	f, err := parser.ParseFile(fset, "severity.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// warnings are reported alongside errors
+	var got []string
+	conf := Config{
+		ReportShadowing: true,
+		Error: func(err error) {
+			e := err.(Error)
+			got = append(got, fmt.Sprintf("%s: %s", e.Severity, e.Msg))
+		},
+	}
+	_, err = conf.Check("p", fset, []*ast.File{f}, nil)
+	want := []string{
+		"warning: declaration of len shadows predeclared identifier",
+		"error: x declared but not used",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+	if err == nil || !strings.Contains(err.Error(), "declared but not used") {
+		t.Errorf("got error %v; want the unused variable error", err)
+	}
+
+	// warnings alone don't cause the check to fail, with or without Config.Error
+	f, err = parser.ParseFile(fset, "severity.go", `package p; func _(len int) {}`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	if _, err := conf.Check("p", fset, []*ast.File{f}, nil); err != nil || len(got) != 1 {
+		t.Errorf("got error %v and diagnostics %q; want no error and 1 warning", err, got)
+	}
+	conf.Error = nil
+	if _, err := conf.Check("p", fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("got error %v; want none", err)
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	parse := func(name, src string) *ast.File {
+		f, err := parser.ParseFile(fset, name, src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	a := parse("a.go", "package p")
+	b := parse("b.go", "package q")
+	x := parse("x_test.go", "package p_test")
+
+	for _, test := range []struct {
+		name  string // Config.PackageName
+		files []*ast.File
+		pkg   string // resulting package name
+		errs  []string
+	}{
+		{"", []*ast.File{a}, "p", nil},
+		{"p", []*ast.File{a}, "p", nil},
+		{"", []*ast.File{a, b}, "p", []string{"package q; expected p (as declared in a.go)"}},
+		{"q", []*ast.File{a, b}, "q", []string{"package p; expected q"}},
+		{"", []*ast.File{a, x}, "p", []string{"package p_test; expected p (external test files must be checked as a separate package)"}},
+		{"_", []*ast.File{a}, "p", []string{"invalid package name _"}},
+	} {
+		var errs []string
+		conf := Config{
+			PackageName: test.name,
+			Error:       func(err error) { errs = append(errs, err.(Error).Msg) },
+		}
+		pkg, _ := conf.Check("p", fset, test.files, nil)
+		if pkg.Name() != test.pkg {
+			t.Errorf("PackageName = %q: got package name %s; want %s", test.name, pkg.Name(), test.pkg)
+		}
+		if fmt.Sprint(errs) != fmt.Sprint(test.errs) {
+			t.Errorf("PackageName = %q: got errors %q; want %q", test.name, errs, test.errs)
+		}
+	}
+
+	// CheckWithTests applies PackageName to the package proper
+	// and expects the external test package to be named accordingly.
+	conf := Config{PackageName: "p"}
+	pkg, xtest, err := conf.CheckWithTests("p", fset, []*ast.File{x, a}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Name() != "p" || xtest == nil || xtest.Name() != "p_test" {
+		t.Errorf("got packages %v and %v; want p and p_test", pkg, xtest)
+	}
+}
+
+func TestFreeVars(t *testing.T) {
+	const src = `package p
+
+type T struct{ f int }
+
+var global int
+
+func _(a, b int, t T) (r int) {
+	var c int
+	_ = func() {
+		_ = func(x int) int { return x + a }
+	}
+	_ = func(d int) int {
+		e := d + c
+		var t T
+		_ = t.f + global + r
+		_ = func() int { return b + e + a }
+		return r
+	}
+	_ = func() {}
+	return
+}
+`
+	f, err := parser.ParseFile(fset, "freevars.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{
+		Uses:   make(map[*ast.Ident]Object),
+		Scopes: make(map[ast.Node]*Scope),
+	}
+	var conf Config
+	if _, err := conf.Check("p", fset, []*ast.File{f}, &info); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		if lit, _ := n.(*ast.FuncLit); lit != nil {
+			var names []string
+			for _, v := range FreeVars(&info, lit) {
+				names = append(names, v.Name())
+			}
+			got = append(got, fmt.Sprint(names))
+		}
+		return true
+	})
+	want := []string{
+		"[a]",       // outer literal, via nested literal
+		"[a]",       // nested literal
+		"[c r b a]", // t is local and t.f is a field; global is package-level
+		"[b e a]",   // e is local to the enclosing literal
+		"[]",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestReplaceDeclarations(t *testing.T) {
+	inputs := []string{
+		`package p; type T int; const c = 1; var x T = c; func f() int { return c }`,
+		`package p; const c = "hello"; var y = c; var z = x`,
+		`package p; type T string; var w T = "s"; func f() string { return c }; var v = f()`,
+	}
+
+	for _, replace := range []bool{false, true} {
+		var errs []string
+		conf := Config{
+			ReplaceDeclarations: replace,
+			Error:               func(err error) { errs = append(errs, err.(Error).Msg) },
+		}
+		pkg := NewPackage("p", "p")
+		info := Info{Defs: make(map[*ast.Ident]Object)}
+		check := NewChecker(&conf, fset, pkg, &info)
+		var oldT Type
+		for i, src := range inputs {
+			f, err := parser.ParseFile(fset, fmt.Sprintf("input%d.go", i), src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			check.Files([]*ast.File{f})
+			if i == 0 {
+				oldT = pkg.Scope().Lookup("T").Type()
+			}
+		}
+
+		if !replace {
+			if len(errs) == 0 {
+				t.Errorf("ReplaceDeclarations = false: got no redeclaration errors")
+			}
+			continue
+		}
+		if len(errs) > 0 {
+			t.Errorf("ReplaceDeclarations = true: got errors %q", errs)
+		}
+
+		scope := pkg.Scope()
+		for _, test := range []struct {
+			name, typ string
+		}{
+			{"c", "untyped string"},
+			{"x", "p.T"},
+			{"y", "string"},
+			{"z", "p.T"},
+			{"w", "p.T"},
+			{"f", "func() string"},
+			{"v", "string"},
+		} {
+			obj := scope.Lookup(test.name)
+			if obj == nil {
+				t.Errorf("%s not found", test.name)
+				continue
+			}
+			if got := obj.Type().String(); got != test.typ {
+				t.Errorf("%s: got type %s; want %s", test.name, got, test.typ)
+			}
+		}
+
+		// earlier declarations refer to the replaced type
+		newT := scope.Lookup("T").Type()
+		if Identical(oldT, newT) {
+			t.Errorf("replaced type T is identical to new type T")
+		}
+		if x := scope.Lookup("x").Type(); x != oldT || !Identical(scope.Lookup("z").Type(), oldT) {
+			t.Errorf("x and z should have the replaced type T")
+		}
+		if scope.Lookup("w").Type() != newT {
+			t.Errorf("w should have the new type T")
+		}
+
+		// redeclarations within a single input are still errors
+		f, err := parser.ParseFile(fset, "input.go", `package p; var a int; var a string`, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check.Files([]*ast.File{f})
+		if len(errs) == 0 || !strings.Contains(errs[0], "a redeclared") {
+			t.Errorf("got errors %q; want redeclaration of a", errs)
+		}
+	}
+}
+
+func TestTypeInferred(t *testing.T) {
+	const src = `package p
+
+var a float64 = 1e-2000
+var b = 1e-2000
+var c, d = 1, "foo"
+var e, f int = 1, 2
+var g, h = m()
+
+func m() (int, bool)
+
+func _(p int) (r int) {
+	var i = 'x'
+	var j byte = 'x'
+	k, l := 1.0, p
+	for n, s := range "foo" {
+		_, _ = n, s
+	}
+	var u, v int
+	for u, v = range []int{} {
+	}
+	switch x := interface{}(nil).(type) {
+	case int:
+		_ = x
+	}
+	_, _, _, _, _, _, _ = i, j, k, l, r, u, v
+	return
+}
+`
+	info := Info{Defs: make(map[*ast.Ident]Object)}
+	mustTypecheck(t, "TypeInferred", src, &info)
+
+	var got []string
+	for id, obj := range info.Defs {
+		if v, _ := obj.(*Var); v != nil && v.TypeInferred() {
+			got = append(got, fmt.Sprintf("%s %s", id.Name, v.Type()))
+		}
+	}
+	sort.Strings(got)
+	want := []string{
+		"b float64",
+		"c int",
+		"d string",
+		"g int",
+		"h bool",
+		"i rune",
+		"k float64",
+		"l int",
+		"n int",
+		"s rune",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestFieldsRecursive(t *testing.T) {
+	const src = `package p
+
+type A struct{ a, X int }
+type B struct{ *A; b int }
+type C struct {
+	A
+	*B
+	c string
+	X bool
+}
+type R struct {
+	*R
+	r int
+}
+type I int
+`
+	pkg, err := pkgFor("fieldsrecursive.go", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		typ  string
+		want string
+	}{
+		{"A", "a X"},
+		{"B", "A a X b"},
+		{"C", "A a X B A a X b c X"},
+		{"R", "R r"},
+		{"I", ""},
+	} {
+		T := pkg.Scope().Lookup(test.typ).Type()
+		for _, typ := range []Type{T, NewPointer(T)} {
+			var got []string
+			for _, f := range FieldsRecursive(typ) {
+				got = append(got, f.Name())
+			}
+			if got := strings.Join(got, " "); got != test.want {
+				t.Errorf("FieldsRecursive(%s) = %s; want %s", typ, got, test.want)
+			}
+		}
+	}
+}
+
+func TestRepresentabilityErrors(t *testing.T) {
+	for _, test := range []struct {
+		src           string
+		value, target string // "" if not set
+	}{
+		{`var b byte = 256`, "256", "byte"},
+		{`type myint int8; var _ myint = 1000`, "1000", "p.myint"},
+		{`var _ int8 = -129.0`, "-129", "int8"},
+		{`var _ uint = 1.5`, "3/2", "uint"},
+		{`const c int16 = 1 << 14; const _ = c * 4`, "65536", "int16"},
+		{`type u8 uint8; const c u8 = 1; const _ = -c`, "-1", "p.u8"},
+		{`var _ = uint8(300)`, "300", "uint8"},
+		{`var _ = int("foo")`, `"foo"`, "int"},
+		{`var _ = string(1) + 1`, "1", "string"},
+		{`var x int; var _ string = x`, "", ""},
+	} {
+		src := "package p; " + test.src
+		f, err := parser.ParseFile(fset, "p.go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var first *Error
+		conf := Config{Error: func(err error) {
+			if first == nil {
+				e := err.(Error)
+				first = &e
+			}
+		}}
+		conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+		if first == nil {
+			t.Errorf("%s: no error reported", test.src)
+			continue
+		}
+		var value, target string
+		if first.Value != nil {
+			value = first.Value.String()
+		}
+		if first.Target != nil {
+			target = first.Target.String()
+		}
+		if value != test.value || target != test.target {
+			t.Errorf("%s: got value %q and target %q (%s); want %q and %q", test.src, value, target, first.Msg, test.value, test.target)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	newPkg := func(path, src string) *Package {
+		f, err := parser.ParseFile(fset, path+".go", src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var conf Config
+		pkg, err := conf.Check(path, fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pkg
+	}
+	packages := make(map[string]*Package)
+	for _, pkg := range []*Package{
+		newPkg("io", `package io; type Reader interface{ Read([]byte) (int, error) }`),
+		newPkg("bytes", `package bytes; type Buffer struct{ buf []byte }; func (b *Buffer) WriteString(s string) {}; type A struct{ X }; type B struct{ X }; type X struct{ f int }; type C struct{ A; B }`),
+		newPkg("example.com/yaml", `package yaml; func Marshal() {}`),
+		newPkg("example.com/yaml.v2", `package yaml; func Marshal() {}; var V int`),
+	} {
+		packages[pkg.Path()] = pkg
+	}
+
+	for _, test := range []struct {
+		name, want string // want is the object or an error substring
+	}{
+		{"io.Reader", "type io.Reader interface{Read([]byte) (int, error)}"},
+		{"io.Reader.Read", "func (io.Reader).Read([]byte) (int, error)"},
+		{"bytes.Buffer", "type bytes.Buffer struct{buf []byte}"},
+		{"bytes.Buffer.WriteString", "func (*bytes.Buffer).WriteString(s string)"},
+		{"bytes.Buffer.buf", "field buf []byte"},
+		{"bytes.C.f", "ambiguous selector f"},
+		{"bytes.C.g", "C has no field or method g"},
+		{"example.com/yaml.Marshal", "func example.com/yaml.Marshal()"},
+		{"example.com/yaml.v2.Marshal", "func example.com/yaml.v2.Marshal()"},
+		{"example.com/yaml.v2.V", "var example.com/yaml.v2.V int"},
+		{"example.com/yaml.V", "V not declared in package example.com/yaml"},
+		{"example.com/yaml.v2.V.x", "V is not a type"},
+		{"bytes.Buffer.WriteString.x", "too many name components"},
+		{"fmt.Println", "no package found"},
+		{"io", "no package found"},
+	} {
+		obj, err := Lookup(packages, test.name)
+		var got string
+		if err != nil {
+			got = err.Error()
+		} else {
+			got = obj.String()
+		}
+		if !strings.Contains(got, test.want) {
+			t.Errorf("Lookup(%q) = %s; want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestTupleComponents(t *testing.T) {
+	const src = `package p
+
+func f() (n int, err error)
+func g() (int, error)
+func h() (_ int, err error)
+
+var m map[string]bool
+var _, _ = m["foo"]
+`
+	f, err := parser.ParseFile(fset, "tuple.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+	var conf Config
+	p, err := conf.Check("p", fset, []*ast.File{f}, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	components := func(tup *Tuple) string {
+		var list []string
+		for i := 0; i < tup.Len(); i++ {
+			v := tup.At(i)
+			list = append(list, fmt.Sprintf("%q %s", v.Name(), v.Type()))
+		}
+		return strings.Join(list, ", ")
+	}
+	for _, test := range []struct {
+		name, want, str string
+	}{
+		{"f", `"n" int, "err" error`, "(n int, err error)"},
+		{"g", `"" int, "" error`, "(int, error)"},
+		{"h", `"_" int, "err" error`, "(_ int, err error)"},
+	} {
+		res := p.Scope().Lookup(test.name).Type().(*Signature).Results()
+		if got := components(res); got != test.want {
+			t.Errorf("%s: got results %s; want %s", test.name, got, test.want)
+		}
+		if got := res.String(); got != test.str {
+			t.Errorf("%s: got %s; want %s", test.name, got, test.str)
+		}
+	}
+
+	// comma-ok expressions
+	found := false
+	for e, tv := range info.Types {
+		if ExprString(e) == `m["foo"]` {
+			found = true
+			tup, _ := tv.Type.(*Tuple)
+			if got, want := components(tup), `"" bool, "" bool`; got != want {
+				t.Errorf("got %s; want %s", got, want)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("no type recorded for comma-ok expression")
+	}
+}
+
+func TestOnPackageChecked(t *testing.T) {
+	var checked []*Package
+	var ntypes []int
+	info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+	conf := Config{OnPackageChecked: func(pkg *Package) {
+		checked = append(checked, pkg)
+		ntypes = append(ntypes, len(info.Types))
+	}}
+
+	f, err := parser.ParseFile(fset, "p.go", "package p; var x = 1 + 2", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checked) != 1 || checked[0] != pkg {
+		t.Fatalf("OnPackageChecked called with %v; want [%v]", checked, pkg)
+	}
+	if ntypes[0] == 0 || ntypes[0] != len(info.Types) {
+		t.Errorf("Info.Types had %d entries when OnPackageChecked was called; want %d", ntypes[0], len(info.Types))
+	}
+
+	// not called for packages with errors
+	checked = nil
+	conf.Error = func(error) {}
+	f, err = parser.ParseFile(fset, "q.go", "package q; var x int = `s`", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conf.Check("q", fset, []*ast.File{f}, nil); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(checked) != 0 {
+		t.Errorf("OnPackageChecked called for package with errors: %v", checked)
+	}
+}
+
+func TestRedundantConversions(t *testing.T) {
+	const src = `package p
+
+type T int
+
+var (
+	i int
+	t T
+	b []byte
+
+	_ = int(i)      // redundant
+	_ = T(t)        // redundant
+	_ = ([]byte)(b) // redundant
+	_ = T(i)
+	_ = int(t)
+	_ = int(1)
+	_ = string(b)
+	_ = float64(1 << 2)
+	_ = int(int(i)) // outer and inner redundant
+)
+`
+	info := Info{RedundantConversions: make(map[*ast.CallExpr]bool)}
+	mustTypecheck(t, "p", src, &info)
+
+	var got []string
+	for call := range info.RedundantConversions {
+		got = append(got, ExprString(call))
+	}
+	sort.Strings(got)
+	want := []string{"T(t)", "([]byte)(b)", "int(i)", "int(i)", "int(int(i))"}
+	sort.Strings(want)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got redundant conversions %v; want %v", got, want)
+	}
+}
+
+func TestMakeSignature(t *testing.T) {
+	sig := MakeSignature([]Type{Typ[Int], NewSlice(Typ[String])}, []Type{Universe.Lookup("error").Type()}, true)
+	if got, want := sig.String(), "func(int, ...string) error"; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+	if got := MakeSignature(nil, nil, false).String(); got != "func()" {
+		t.Errorf("got %s; want func()", got)
+	}
+
+	for _, params := range [][]Type{nil, {Typ[Int], Typ[String]}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("MakeSignature(%v, nil, true) did not panic", params)
+				}
+			}()
+			MakeSignature(params, nil, true)
+		}()
+	}
+}
+
+func TestMutuallyRecursive(t *testing.T) {
+	const src = `package p
+
+type (
+	A struct{ b *B }
+	B []C
+	C map[string]func(A)
+
+	D struct{ next *D }
+	E int
+	F struct{ e E; a A }
+
+	I interface{ m() J }
+	J interface{ I }
+)
+
+func (E) m(F) {}
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	named := func(name string) *Named {
+		return pkg.Scope().Lookup(name).Type().(*Named)
+	}
+
+	for _, test := range []struct {
+		a, b string
+		want bool
+	}{
+		{"A", "B", true},
+		{"B", "A", true},
+		{"A", "C", true},
+		{"A", "A", true},
+		{"D", "D", true},
+		{"E", "E", false},
+		{"E", "F", false}, // methods are ignored
+		{"F", "A", false},
+		{"A", "D", false},
+		{"I", "J", true},
+	} {
+		if got := MutuallyRecursive(named(test.a), named(test.b)); got != test.want {
+			t.Errorf("MutuallyRecursive(%s, %s) = %v; want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestCallKind(t *testing.T) {
+	const src = `package p
+
+type T int
+
+func (T) m() {}
+
+func f(int) T { return 0 }
+
+var (
+	_ = T(1)
+	_ = f(2)
+	_ = len("three")
+	_ = (*int)(nil)
+	_ = T.m
+	_ = func() int { print(); T(0).m(); return 0 }()
+	_ = g(0)
+	_ = T(0)(1)
+)
+`
+	info := Info{CallKind: make(map[*ast.CallExpr]CallKind)}
+	conf := Config{Error: func(error) {}} // collect all errors
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Check("p", fset, []*ast.File{f}, &info)
+
+	var got []string
+	for call, kind := range info.CallKind {
+		got = append(got, fmt.Sprintf("%s:%d", ExprString(call), kind))
+	}
+	sort.Strings(got)
+	want := []string{
+		"(*int)(nil):1",
+		"T(0):1",
+		"T(0):1",
+		"T(0).m():0",
+		"T(1):1",
+		"f(2):0",
+		"(func() int literal)():0",
+		`len("three"):2`,
+		"print():2",
+	}
+	sort.Strings(want)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got call kinds %v; want %v", got, want)
+	}
+}
+
+func TestConcurrentChecks(t *testing.T) {
+	sources := map[string]string{
+		"a": `package a; type T struct{ x int }; func (T) M() int { return 0 }`,
+		"b": `package b; import "a"; type U []a.T; func F() a.T { return a.T{} }`,
+		"c": `package c; import ("a"; "b"); var V = b.F().M(); type I interface{ M() int }; var _ I = a.T{}`,
+	}
+
+	// importer type-checking packages from source
+	var nimports = make(map[string]int)
+	var srcImport Importer
+	srcImport = func(imports map[string]*Package, path string) (*Package, error) {
+		if pkg := imports[path]; pkg != nil {
+			return pkg, nil
+		}
+		nimports[path]++
+		f, err := parser.ParseFile(fset, path+".go", sources[path], 0)
+		if err != nil {
+			return nil, err
+		}
+		conf := Config{Packages: imports, Import: srcImport}
+		pkg, err := conf.Check(path, fset, []*ast.File{f}, nil)
+		if err != nil {
+			return nil, err
+		}
+		imports[path] = pkg
+		return pkg, nil
+	}
+
+	// a shared Config, used concurrently
+	conf := Config{
+		Packages: make(map[string]*Package),
+		Import:   SerialImporter(srcImport),
+	}
+
+	const n = 50
+	var wg sync.WaitGroup
+	pkgs := make([]*Package, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			src := fmt.Sprintf(`package p%d
+import ("a"; "b"; "c")
+var x b.U = append(b.U(nil), b.F())
+var _ = x[0].M() + c.V
+var _ c.I = x[0]
+var _ = a.T{}
+`, i)
+			f, err := parser.ParseFile(fset, fmt.Sprintf("p%d.go", i), src, 0)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+			pkgs[i], errs[i] = conf.Check(fmt.Sprintf("p%d", i), fset, []*ast.File{f}, &info)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("p%d: %s", i, err)
+		}
+	}
+	for path, count := range nimports {
+		if count != 1 {
+			t.Errorf("package %s imported %d times; want 1", path, count)
+		}
+	}
+	a := conf.Packages["a"]
+	for i, pkg := range pkgs {
+		if got := pkg.Imports()[0]; got != a {
+			t.Errorf("p%d imports %p as package a; want %p", i, got, a)
+		}
+	}
+}
+
+func TestCommonType(t *testing.T) {
+	ptr := NewPointer(Typ[Int])
+	empty := NewInterface(nil, nil).Complete()
+	for _, test := range []struct {
+		types []Type
+		want  Type // nil if there is no common type
+	}{
+		{nil, nil},
+		{[]Type{Typ[Int]}, Typ[Int]},
+		{[]Type{Typ[UntypedInt], Typ[UntypedFloat]}, Typ[UntypedFloat]},
+		{[]Type{Typ[UntypedComplex], Typ[UntypedRune], Typ[UntypedInt]}, Typ[UntypedComplex]},
+		{[]Type{Typ[UntypedInt], Typ[Float64], Typ[UntypedFloat]}, Typ[Float64]},
+		{[]Type{Typ[UntypedString], Typ[String]}, Typ[String]},
+		{[]Type{Typ[UntypedBool], Typ[UntypedBool]}, Typ[UntypedBool]},
+		{[]Type{Typ[UntypedNil], Typ[UntypedNil]}, Typ[UntypedNil]},
+		{[]Type{Typ[UntypedNil], ptr, Typ[UntypedNil]}, ptr},
+		{[]Type{Typ[UntypedNil], Typ[UnsafePointer]}, Typ[UnsafePointer]},
+		{[]Type{empty, Typ[UntypedInt], Typ[UntypedNil]}, empty},
+
+		{[]Type{Typ[Int], Typ[Int64]}, nil},
+		{[]Type{Typ[UntypedInt], Typ[UntypedString]}, nil},
+		{[]Type{Typ[UntypedNil], Typ[UntypedInt]}, nil},
+		{[]Type{Typ[UntypedNil], Typ[Int]}, nil},
+		{[]Type{Typ[UntypedFloat], Typ[String]}, nil},
+		{[]Type{Typ[UntypedInt], ptr}, nil},
+		{[]Type{Typ[UntypedBool], Typ[Int]}, nil},
+	} {
+		got, ok := CommonType(test.types)
+		if got != test.want || ok != (test.want != nil) {
+			t.Errorf("CommonType(%v) = %v, %v; want %v", test.types, got, ok, test.want)
+		}
+	}
+}
+
+func TestExportedMethodSet(t *testing.T) {
+	const src = `package p
+
+type T struct {
+	*e
+	E
+}
+
+func (T) M()  {}
+func (*T) m() {}
+func (*T) P() {}
+
+type e struct{}
+
+func (e) Promoted() {}
+func (e) hidden()   {}
+
+type E int
+
+func (E) N() {}
+`
+	pkg, err := pkgFor("p", src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	T := pkg.Scope().Lookup("T").Type()
+
+	for _, test := range []struct {
+		typ  Type
+		want string
+	}{
+		{T, "[M:[0] N:[1 0] Promoted:[0 0]]"},
+		{NewPointer(T), "[M:[0] N:[1 0] P:[2] Promoted:[0 0]]"},
+		{pkg.Scope().Lookup("e").Type(), "[Promoted:[0]]"},
+	} {
+		mset := ExportedMethodSet(test.typ)
+		var got []string
+		for i := 0; i < mset.Len(); i++ {
+			sel := mset.At(i)
+			got = append(got, fmt.Sprintf("%s:%v", sel.Obj().Name(), sel.Index()))
+		}
+		if fmt.Sprint(got) != test.want {
+			t.Errorf("ExportedMethodSet(%s) = %v; want %s", test.typ, got, test.want)
+		}
+		if mset.Lookup(pkg, "hidden") != nil || mset.Lookup(pkg, "m") != nil {
+			t.Errorf("ExportedMethodSet(%s) contains unexported methods", test.typ)
+		}
+	}
+
+	if got := ExportedMethodSet(Typ[Int]).Len(); got != 0 {
+		t.Errorf("ExportedMethodSet(int) has %d methods; want 0", got)
+	}
+}
+
+func TestResolveMethod(t *testing.T) {
+	const src = `package p
+
+type A struct {
+	*B
+	C
+}
+
+type B struct {
+	b int
+	D
+}
+
+func (B) f(int) {}
+
+type C struct {
+	c int
+	I
+}
+
+func (C) g()  {}
+func (*C) h() {}
+
+type D struct{}
+
+func (*D) d() {}
+
+type I interface{ i() }
+
+type X struct {
+	B
+	b2 B
+}
+
+var (
+	_ = new(A).f
+	_ = new(A).g
+	_ = new(A).h
+	_ = new(A).d
+	_ = A{}.f
+	_ = A{}.g
+	_ = A{}.d
+	_ = A{}.i
+	_ = new(X).d
+)
+`
+	info := Info{Selections: make(map[*ast.SelectorExpr]*Selection)}
+	pkg, err := pkgFor("p", src, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	for e, sel := range info.Selections {
+		if sel.Kind() != MethodVal {
+			continue
+		}
+		n++
+		m, err := ResolveMethod(sel.Recv(), e.Sel.Name, pkg)
+		if err != nil {
+			t.Errorf("%s: %s", ExprString(e), err)
+			continue
+		}
+		if m != sel.Obj() {
+			t.Errorf("%s: got %s; want %s (index %v)", ExprString(e), m, sel.Obj(), sel.Index())
+		}
+	}
+	if n != 9 {
+		t.Errorf("got %d method selections; want 9", n)
+	}
+
+	A := pkg.Scope().Lookup("A").Type()
+	for _, test := range []struct {
+		typ  Type
+		name string
+		want string // method or error
+	}{
+		{NewPointer(A), "h", "func (*p.C).h()"},
+		{NewPointer(A), "i", "func (p.I).i()"},
+		{A, "h", "h is not in method set of p.A"},
+		{A, "c", "p.A.c is a field, not a method"},
+		{A, "x", "p.A has no field or method x"},
+		{pkg.Scope().Lookup("X").Type(), "f", "func (p.B).f(int)"},
+	} {
+		var got string
+		if m, err := ResolveMethod(test.typ, test.name, pkg); err != nil {
+			got = err.Error()
+		} else {
+			got = m.String()
+		}
+		if got != test.want {
+			t.Errorf("ResolveMethod(%s, %s) = %s; want %s", test.typ, test.name, got, test.want)
+		}
+	}
+}
+
+func TestConstantOperandsInfo(t *testing.T) {
+	const src = `package p
+
+import "unsafe"
+
+const c = 10
+
+type T int
+
+var (
+	_       = (1 + 2) * c
+	x int64 = (1 + 2) * 3
+	_       = -(c + 1) << (2 - 1)
+	_       = T(1+2) + T(len("abc"))
+	_       = float32(1e-200) + 1
+	_       = 1 < 2 && "a"+"b" == "ab"
+	_       = unsafe.Sizeof(1+2) + 1
+	_       = [1 + 2]int{1 + 1: int(^uint8(1 + 1))}
+	_       = x + (1 + 2)
+)
+`
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+	if _, err := new(Config).Check("p", fset, []*ast.File{f}, &info); err != nil {
+		t.Fatal(err)
+	}
+
+	// all operands of constant expressions are recorded with their values
+	for _, decl := range f.Decls {
+		ast.Inspect(decl, func(n ast.Node) bool {
+			var operands []ast.Expr
+			switch n := n.(type) {
+			case *ast.BasicLit:
+				operands = []ast.Expr{n}
+			case *ast.BinaryExpr:
+				operands = []ast.Expr{n.X, n.Y}
+			case *ast.UnaryExpr:
+				operands = []ast.Expr{n.X}
+			case *ast.ParenExpr:
+				operands = []ast.Expr{n.X}
+			case *ast.CallExpr:
+				if tv := info.Types[n.Fun]; tv.IsType() {
+					operands = n.Args // conversion
+				}
+			case *ast.ImportSpec:
+				return false
+			}
+			for _, x := range operands {
+				if tv, ok := info.Types[x]; ok && tv.Value == nil && tv.IsValue() && info.Types[n.(ast.Expr)].Value != nil {
+					t.Errorf("%s: operand %s of constant expression has no recorded value", fset.Position(x.Pos()), ExprString(x))
+				} else if !ok {
+					t.Errorf("%s: operand %s is not recorded", fset.Position(x.Pos()), ExprString(x))
+				}
+			}
+			return true
+		})
+	}
+
+	// step-by-step folding of the initialization expression of x
+	var got []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		if e, _ := n.(ast.Expr); e != nil && fset.Position(e.Pos()).Line == 11 {
+			if tv := info.Types[e]; tv.Value != nil {
+				got = append(got, fmt.Sprintf("%s: %s = %s", ExprString(e), tv.Type, tv.Value))
+			}
+		}
+		return true
+	})
+	want := []string{
+		"(1 + 2) * 3: int64 = 9",
+		"(1 + 2): untyped int = 3",
+		"1 + 2: untyped int = 3",
+		"1: untyped int = 1",
+		"2: untyped int = 2",
+		"3: untyped int = 3",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+
+	// constant conversion arguments are recorded with their rounded values
+	for e, tv := range info.Types {
+		if call, _ := e.(*ast.CallExpr); call != nil && ExprString(call.Fun) == "float32" {
+			if got := tv.Value.String(); got != "0" {
+				t.Errorf("%s recorded with value %s; want 0", ExprString(call), got)
+			}
+			if tv := info.Types[call.Args[0]]; tv.Type != Typ[Float32] || tv.Value.String() != "0" {
+				t.Errorf("1e-200 recorded as %s with value %s; want float32 0", tv.Type, tv.Value)
+			}
+		}
+	}
+}
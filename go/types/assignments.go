@@ -62,7 +62,16 @@ func (check *Checker) assignment(x *operand, T Type) bool {
 	// spec: "If a left-hand side is the blank identifier, any typed or
 	// non-constant value except for the predeclared identifier nil may
 	// be assigned to it."
-	return T == nil || x.assignableTo(check.conf, T)
+	if T == nil {
+		return true
+	}
+	if !x.assignableTo(check.conf, T, nil) {
+		return false
+	}
+	if isInterface(T) && !isInterface(x.typ) && x.typ != Typ[UntypedNil] && x.expr != nil {
+		check.recordConversion(x.expr, x.typ, T)
+	}
+	return true
 }
 
 func (check *Checker) initConst(lhs *Const, x *operand) {
@@ -205,7 +214,8 @@ func (check *Checker) assignVar(lhs ast.Expr, x *operand) Type {
 // return expressions, and returnPos is the position of the return statement.
 func (check *Checker) initVars(lhs []*Var, rhs []ast.Expr, returnPos token.Pos) {
 	l := len(lhs)
-	get, r, commaOk := unpack(func(x *operand, i int) { check.expr(x, rhs[i]) }, len(rhs), l == 2 && !returnPos.IsValid())
+	calls := make([]bool, len(rhs))
+	get, r, commaOk := unpack(func(x *operand, i int) { calls[i] = check.exprKind(x, rhs[i]) == statement }, len(rhs), l == 2 && !returnPos.IsValid())
 	if get == nil || l != r {
 		// invalidate lhs and use rhs
 		for _, obj := range lhs {
@@ -236,15 +246,21 @@ func (check *Checker) initVars(lhs []*Var, rhs []ast.Expr, returnPos token.Pos)
 		return
 	}
 
+	var blank []bool
 	for i, lhs := range lhs {
 		get(&x, i)
 		check.initVar(lhs, &x, returnPos.IsValid())
+		blank = append(blank, lhs.name == "_")
+	}
+	if !returnPos.IsValid() {
+		check.blankResults(blank, rhs, calls)
 	}
 }
 
 func (check *Checker) assignVars(lhs, rhs []ast.Expr) {
 	l := len(lhs)
-	get, r, commaOk := unpack(func(x *operand, i int) { check.expr(x, rhs[i]) }, len(rhs), l == 2)
+	calls := make([]bool, len(rhs))
+	get, r, commaOk := unpack(func(x *operand, i int) { calls[i] = check.exprKind(x, rhs[i]) == statement }, len(rhs), l == 2)
 	if get == nil {
 		return // error reported by unpack
 	}
@@ -265,9 +281,36 @@ func (check *Checker) assignVars(lhs, rhs []ast.Expr) {
 		return
 	}
 
+	var blank []bool
 	for i, lhs := range lhs {
 		get(&x, i)
 		check.assignVar(lhs, &x)
+		ident, _ := unparen(lhs).(*ast.Ident)
+		blank = append(blank, ident != nil && ident.Name == "_")
+	}
+	check.blankResults(blank, rhs, calls)
+}
+
+// blankResults records the results of function calls in rhs that are
+// assigned to the blank identifier as unused; blank[i] reports whether
+// the i'th lhs operand is the blank identifier, and calls[i] reports
+// whether rhs[i] may appear in statement context (which excludes
+// conversions and calls of most built-in functions).
+func (check *Checker) blankResults(blank []bool, rhs []ast.Expr, calls []bool) {
+	for i, rhs := range rhs {
+		call, _ := unparen(rhs).(*ast.CallExpr)
+		if call == nil || !calls[i] {
+			continue
+		}
+		switch {
+		case len(blank) == len(calls):
+			if blank[i] {
+				check.recordUnusedResults(call, []bool{true})
+			}
+		case len(calls) == 1:
+			// n-valued function call
+			check.recordUnusedResults(call, blank)
+		}
 	}
 }
 
@@ -296,6 +339,7 @@ func (check *Checker) shortVarDecl(pos token.Pos, lhs, rhs []ast.Expr) {
 			} else {
 				// declare new variable, possibly a blank (_) variable
 				obj = NewVar(ident.Pos(), check.pkg, name, nil)
+				obj.inferred = true
 				if name != "_" {
 					newVars = append(newVars, obj)
 				}
@@ -0,0 +1,69 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package buildcheck type-checks packages described by go/build.
+//
+// It provides the glue between a *build.Package, as returned by
+// build.Import or build.ImportDir, and types.Config.Check, so that
+// tools need not repeat the steps from a package directory to type
+// information. Use go/loader for programs consisting of several
+// packages loaded from source.
+package buildcheck
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+
+	"golang.org/x/tools/go/buildutil"
+	"golang.org/x/tools/go/types"
+)
+
+// Check parses the GoFiles and CgoFiles of bp and type-checks them as
+// package bp.ImportPath using the type-checker configuration conf (a
+// nil conf is treated like an empty Config). All files are read through
+// ctxt's file system interface, resolving file names relative to bp.Dir,
+// and are added to fset.
+//
+// If bp has CgoFiles, they are checked with conf.FakeImportC set, since
+// the code generated by cgo is not available: references to package C
+// are not resolved. conf itself is not modified.
+//
+// The result is the type-checked package and an Info with populated
+// Types, Defs, Uses, Implicits, Selections, and Scopes maps. As for
+// Config.Check, the package may be non-nil and partially complete if
+// there were type errors; parse errors are returned before type-checking.
+//
+func Check(conf *types.Config, ctxt *build.Context, fset *token.FileSet, bp *build.Package) (*types.Package, *types.Info, error) {
+	var files []*ast.File
+	for _, list := range [][]string{bp.GoFiles, bp.CgoFiles} {
+		for _, name := range list {
+			f, err := buildutil.ParseFile(fset, ctxt, nil, bp.Dir, name, parser.ParseComments)
+			if err != nil {
+				return nil, nil, err
+			}
+			files = append(files, f)
+		}
+	}
+
+	var c types.Config
+	if conf != nil {
+		c = *conf
+	}
+	if len(bp.CgoFiles) > 0 {
+		c.FakeImportC = true
+	}
+
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+	pkg, err := c.Check(bp.ImportPath, fset, files, info)
+	return pkg, info, err
+}
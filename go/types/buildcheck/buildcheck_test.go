@@ -0,0 +1,72 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buildcheck_test
+
+import (
+	"bytes"
+	"fmt"
+	"go/build"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/types/buildcheck"
+)
+
+// fakeContext returns a build context that reads files from the given map,
+// keyed by absolute file name.
+func fakeContext(files map[string]string) *build.Context {
+	ctxt := build.Default // copy
+	ctxt.OpenFile = func(path string) (io.ReadCloser, error) {
+		src, ok := files[filepath.ToSlash(path)]
+		if !ok {
+			return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+		}
+		return ioutil.NopCloser(bytes.NewBufferString(src)), nil
+	}
+	return &ctxt
+}
+
+func TestCheck(t *testing.T) {
+	ctxt := fakeContext(map[string]string{
+		"/go/src/p/a.go": `package p; var A = B + 1`,
+		"/go/src/p/b.go": `package p; const B = 41`,
+		"/go/src/p/c.go": `package p; import "C"; var X = C.x`,
+	})
+	bp := &build.Package{
+		Dir:        "/go/src/p",
+		Name:       "p",
+		ImportPath: "p",
+		GoFiles:    []string{"a.go", "b.go"},
+		CgoFiles:   []string{"c.go"},
+	}
+
+	fset := token.NewFileSet()
+	pkg, info, err := buildcheck.Check(nil, ctxt, fset, bp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Path() != "p" || pkg.Name() != "p" {
+		t.Errorf("got package %s %q; want p %q", pkg.Name(), pkg.Path(), "p")
+	}
+	if got := fmt.Sprint(pkg.Scope().Lookup("A")); got != "var p.A int" {
+		t.Errorf("got %s; want var p.A int", got)
+	}
+	if len(info.Defs) == 0 || len(info.Types) == 0 {
+		t.Errorf("Info was not populated")
+	}
+	if got := fset.Position(pkg.Scope().Lookup("B").Pos()).Filename; got != "/go/src/p/b.go" {
+		t.Errorf("got file name %s for B; want /go/src/p/b.go", got)
+	}
+
+	// a missing file
+	bp.GoFiles = append(bp.GoFiles, "missing.go")
+	if _, _, err := buildcheck.Check(nil, ctxt, token.NewFileSet(), bp); err == nil {
+		t.Errorf("got no error for missing file")
+	}
+}
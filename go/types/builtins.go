@@ -7,6 +7,7 @@
 package types
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
 
@@ -97,13 +98,13 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		// spec: "As a special case, append also accepts a first argument assignable
 		// to type []byte with a second argument of string type followed by ... .
 		// This form appends the bytes of the string.
-		if nargs == 2 && call.Ellipsis.IsValid() && x.assignableTo(check.conf, NewSlice(UniverseByte)) {
+		if nargs == 2 && call.Ellipsis.IsValid() && x.assignableTo(check.conf, NewSlice(UniverseByte), nil) {
 			arg(x, 1)
 			if x.mode == invalid {
 				return
 			}
 			if isString(x.typ) {
-				if check.Types != nil {
+				if check.recordTypes() {
 					sig := makeSig(S, S, x.typ)
 					sig.variadic = true
 					check.recordBuiltinType(call.Fun, sig)
@@ -131,7 +132,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 
 		x.mode = value
 		x.typ = S
-		if check.Types != nil {
+		if check.recordTypes() {
 			check.recordBuiltinType(call.Fun, sig)
 		}
 
@@ -180,7 +181,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		x.mode = mode
 		x.typ = Typ[Int]
 		x.val = val
-		if check.Types != nil && mode != constant {
+		if check.recordTypes() && mode != constant {
 			check.recordBuiltinType(call.Fun, makeSig(x.typ, typ))
 		}
 
@@ -197,7 +198,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		}
 
 		x.mode = novalue
-		if check.Types != nil {
+		if check.recordTypes() {
 			check.recordBuiltinType(call.Fun, makeSig(nil, c))
 		}
 
@@ -259,7 +260,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		}
 
 		x.typ = complexT
-		if check.Types != nil && x.mode != constant {
+		if check.recordTypes() && x.mode != constant {
 			check.recordBuiltinType(call.Fun, makeSig(complexT, realT, realT))
 		}
 
@@ -306,7 +307,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			return
 		}
 
-		if check.Types != nil {
+		if check.recordTypes() {
 			check.recordBuiltinType(call.Fun, makeSig(Typ[Int], x.typ, y.typ))
 		}
 		x.mode = value
@@ -324,13 +325,13 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			return
 		}
 
-		if !x.assignableTo(check.conf, m.key) {
+		if !x.assignableTo(check.conf, m.key, nil) {
 			check.invalidArg(x.pos(), "%s is not assignable to %s", x, m.key)
 			return
 		}
 
 		x.mode = novalue
-		if check.Types != nil {
+		if check.recordTypes() {
 			check.recordBuiltinType(call.Fun, makeSig(nil, m, m.key))
 		}
 
@@ -362,7 +363,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 			unreachable()
 		}
 
-		if check.Types != nil && x.mode != constant {
+		if check.recordTypes() && x.mode != constant {
 			check.recordBuiltinType(call.Fun, makeSig(Typ[k], x.typ))
 		}
 		x.typ = Typ[k]
@@ -403,9 +404,9 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		}
 		x.mode = value
 		x.typ = T
-		if check.Types != nil {
+		if check.recordTypes() {
 			params := [...]Type{T, Typ[Int], Typ[Int]}
-			check.recordBuiltinType(call.Fun, makeSig(x.typ, params[:1+len(sizes)]...))
+			check.recordBuiltinType(call.Fun, makeSig(x.typ, params[:nargs]...))
 		}
 
 	case _New:
@@ -418,7 +419,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 
 		x.mode = value
 		x.typ = &Pointer{base: T}
-		if check.Types != nil {
+		if check.recordTypes() {
 			check.recordBuiltinType(call.Fun, makeSig(x.typ, T))
 		}
 
@@ -431,7 +432,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		}
 
 		x.mode = novalue
-		if check.Types != nil {
+		if check.recordTypes() {
 			check.recordBuiltinType(call.Fun, makeSig(nil, T))
 		}
 
@@ -454,7 +455,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		}
 
 		x.mode = novalue
-		if check.Types != nil {
+		if check.recordTypes() {
 			check.recordBuiltinType(call.Fun, makeSig(nil, params...))
 		}
 
@@ -462,7 +463,7 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 		// recover() interface{}
 		x.mode = value
 		x.typ = new(Interface)
-		if check.Types != nil {
+		if check.recordTypes() {
 			check.recordBuiltinType(call.Fun, makeSig(x.typ))
 		}
 
@@ -580,6 +581,113 @@ func (check *Checker) builtin(x *operand, call *ast.CallExpr, id builtinId) (_ b
 	return true
 }
 
+// BuiltinSignature returns the signature of the built-in function
+// with the given name as specialized by the type checker for a call
+// with arguments of the given types. The name may also denote one of
+// the functions of package unsafe (e.g., "Sizeof"). The first argument
+// of make and new is the type operand. For calls that produce a constant
+// result (e.g., len of an array), the signature is made up of the argument
+// types and the respective result type. Untyped arguments are replaced by
+// their default types in the signature.
+//
+// For example, BuiltinSignature("append", []Type{NewSlice(Typ[Int]), Typ[Int]})
+// returns the signature func([]int, ...int) []int.
+//
+// An error is returned if name does not denote a built-in function or
+// if the arguments are invalid for a call of that built-in. The special
+// forms append(b, s...) and unsafe.Offsetof(x.f) are not supported.
+//
+func BuiltinSignature(name string, args []Type) (*Signature, error) {
+	obj, _ := Universe.Lookup(name).(*Builtin)
+	if obj == nil {
+		obj, _ = Unsafe.scope.Lookup(name).(*Builtin)
+	}
+	if obj == nil {
+		return nil, fmt.Errorf("%s is not a built-in function", name)
+	}
+	if obj.id == _Offsetof {
+		return nil, fmt.Errorf("signature of built-in %s is not supported", name)
+	}
+
+	// Declare the arguments in a scope of their own and
+	// type-check a synthesized call of the built-in.
+	// Untyped arguments are passed as constants of their
+	// kind since there are no variables of untyped type.
+	scope := NewScope(Universe, "builtin call")
+	scope.Insert(obj)
+	call := &ast.CallExpr{Fun: ast.NewIdent(name)}
+	for i, typ := range args {
+		if typ == nil {
+			return nil, fmt.Errorf("argument %d of %s has no type", i, name)
+		}
+		if i == 0 && (obj.id == _Make || obj.id == _New) {
+			if isUntyped(typ) {
+				return nil, fmt.Errorf("%s is not a type", typ)
+			}
+			argName := fmt.Sprintf("x%d", i)
+			scope.Insert(NewTypeName(token.NoPos, nil, argName, typ))
+			call.Args = append(call.Args, ast.NewIdent(argName))
+			continue
+		}
+		if isUntyped(typ) {
+			call.Args = append(call.Args, untypedOperand(typ.(*Basic)))
+			continue
+		}
+		argName := fmt.Sprintf("x%d", i)
+		scope.Insert(NewVar(token.NoPos, nil, argName, typ))
+		call.Args = append(call.Args, ast.NewIdent(argName))
+	}
+
+	info := &Info{Types: make(map[ast.Expr]TypeAndValue)}
+	check := NewChecker(nil, token.NewFileSet(), nil, info)
+	check.scope = scope
+
+	var x operand
+	if err := func() (err error) {
+		defer check.handleBailout(&err)
+		check.rawExpr(&x, call, nil)
+		return
+	}(); err != nil {
+		return nil, err
+	}
+	if x.mode == invalid {
+		return nil, fmt.Errorf("invalid call of built-in %s", name)
+	}
+	if sig, _ := info.Types[call.Fun].Type.(*Signature); sig != nil {
+		return sig, nil
+	}
+
+	// The signature is not recorded for calls with constant result.
+	var res Type
+	if x.mode != novalue {
+		res = defaultType(x.typ)
+	}
+	return makeSig(res, args...), nil
+}
+
+// untypedOperand returns a constant expression of the untyped type typ.
+//
+func untypedOperand(typ *Basic) ast.Expr {
+	switch typ.kind {
+	case UntypedBool:
+		return ast.NewIdent("true")
+	case UntypedInt:
+		return &ast.BasicLit{Kind: token.INT, Value: "0"}
+	case UntypedRune:
+		return &ast.BasicLit{Kind: token.CHAR, Value: "'\\x00'"}
+	case UntypedFloat:
+		return &ast.BasicLit{Kind: token.FLOAT, Value: "0.0"}
+	case UntypedComplex:
+		return &ast.BasicLit{Kind: token.IMAG, Value: "0i"}
+	case UntypedString:
+		return &ast.BasicLit{Kind: token.STRING, Value: `""`}
+	case UntypedNil:
+		return ast.NewIdent("nil")
+	}
+	unreachable()
+	return nil
+}
+
 // makeSig makes a signature for the given argument and result types.
 // Default types are used for untyped arguments, and res may be nil.
 func makeSig(res Type, args ...Type) *Signature {
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"go/ast"
 	"go/parser"
+	"strings"
 	"testing"
 
 	_ "golang.org/x/tools/go/gcimporter"
@@ -70,6 +71,7 @@ var builtinCalls = []struct {
 
 	{"make", `_ = make([]int, 10)`, `func([]int, int) []int`},
 	{"make", `type T []byte; _ = make(T, 10, 20)`, `func(p.T, int, int) p.T`},
+	{"make", `var n int; _ = make([]int, n)`, `func([]int, int) []int`},
 
 	{"new", `_ = new(int)`, `func(int) *int`},
 	{"new", `type T struct{}; _ = new(T)`, `func(p.T) *p.T`},
@@ -202,3 +204,61 @@ func testBuiltinSignature(t *testing.T, name, src0, want string) {
 		}
 	}
 }
+
+func TestBuiltinSignatureLookup(t *testing.T) {
+	intSlice := NewSlice(Typ[Int])
+	m := NewMap(Typ[String], Typ[Int])
+	for _, test := range []struct {
+		name string
+		args []Type
+		want string // signature, or error text if prefixed by "error: "
+	}{
+		{"append", []Type{intSlice, Typ[Int], Typ[Int]}, "func([]int, ...int) []int"},
+		{"append", []Type{intSlice, Typ[UntypedInt]}, "func([]int, ...int) []int"},
+		{"len", []Type{Typ[String]}, "func(string) int"},
+		{"len", []Type{NewArray(Typ[Int], 3)}, "func([3]int) int"}, // constant result
+		{"make", []Type{intSlice, Typ[Int]}, "func([]int, int) []int"},
+		{"new", []Type{m}, "func(map[string]int) *map[string]int"},
+		{"copy", []Type{intSlice, intSlice}, "func([]int, []int) int"},
+		{"delete", []Type{m, Typ[String]}, "func(map[string]int, string)"},
+		{"complex", []Type{Typ[Float32], Typ[Float32]}, "func(float32, float32) complex64"},
+		{"Sizeof", []Type{Typ[Int64]}, "func(int64) uintptr"},
+
+		{"foo", nil, "error: foo is not a built-in function"},
+		{"Offsetof", nil, "error: signature of built-in Offsetof is not supported"},
+		{"len", []Type{Typ[Int]}, "error: invalid argument"},
+		{"append", []Type{Typ[Int]}, "error: "},
+		{"make", []Type{Typ[Int]}, "error: "},
+		{"len", []Type{Typ[Invalid]}, "error: invalid call of built-in len"},
+		{"make", []Type{Typ[Invalid]}, "error: invalid call of built-in make"},
+		{"len", []Type{nil}, "error: argument 0 of len has no type"},
+		{"new", []Type{nil}, "error: argument 0 of new has no type"},
+		{"new", []Type{Typ[UntypedInt]}, "error: untyped int is not a type"},
+		{"make", []Type{Typ[UntypedBool]}, "error: untyped bool is not a type"},
+
+		{"len", []Type{Typ[UntypedString]}, "func(string) int"},
+		{"make", []Type{intSlice, Typ[UntypedInt]}, "func([]int, int) []int"},
+		{"complex", []Type{Typ[UntypedFloat], Typ[UntypedFloat]}, "func(float64, float64) complex128"},
+		{"real", []Type{Typ[UntypedComplex]}, "func(complex128) float64"},
+		{"append", []Type{NewSlice(Typ[Int32]), Typ[UntypedRune]}, "func([]int32, ...int32) []int32"},
+		{"print", []Type{Typ[UntypedBool]}, "func(bool)"},
+		{"append", []Type{intSlice, Typ[UntypedNil]}, "error: "},
+	} {
+		sig, err := BuiltinSignature(test.name, test.args)
+		if strings.HasPrefix(test.want, "error: ") {
+			if err == nil {
+				t.Errorf("%s%v: got %s; want error", test.name, test.args, sig)
+			} else if want := strings.TrimPrefix(test.want, "error: "); !strings.Contains(err.Error(), want) {
+				t.Errorf("%s%v: got error %q; want %q", test.name, test.args, err, want)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s%v: %s", test.name, test.args, err)
+			continue
+		}
+		if got := sig.String(); got != test.want {
+			t.Errorf("%s%v: got %s; want %s", test.name, test.args, got, test.want)
+		}
+	}
+}
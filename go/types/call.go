@@ -23,6 +23,7 @@ func (check *Checker) call(x *operand, e *ast.CallExpr) exprKind {
 
 	case typexpr:
 		// conversion
+		check.recordCallKind(e, Conversion)
 		T := x.typ
 		x.mode = invalid
 		switch n := len(e.Args); n {
@@ -31,7 +32,11 @@ func (check *Checker) call(x *operand, e *ast.CallExpr) exprKind {
 		case 1:
 			check.expr(x, e.Args[0])
 			if x.mode != invalid {
+				redundant := isTyped(x.typ) && Identical(x.typ, T)
 				check.conversion(x, T)
+				if redundant && x.mode != invalid {
+					check.recordRedundantConversion(e)
+				}
 			}
 		default:
 			check.errorf(e.Args[n-1].Pos(), "too many arguments in conversion to %s", T)
@@ -40,6 +45,7 @@ func (check *Checker) call(x *operand, e *ast.CallExpr) exprKind {
 		return conversion
 
 	case builtin:
+		check.recordCallKind(e, BuiltinCall)
 		id := x.id
 		if !check.builtin(x, e, id) {
 			x.mode = invalid
@@ -60,6 +66,7 @@ func (check *Checker) call(x *operand, e *ast.CallExpr) exprKind {
 			x.expr = e
 			return statement
 		}
+		check.recordCallKind(e, FuncCall)
 
 		arg, n, _ := unpack(func(x *operand, i int) { check.expr(x, e.Args[i]) }, len(e.Args), false)
 		if arg == nil {
@@ -283,6 +290,7 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 				// ok to continue
 			}
 			check.recordUse(e.Sel, exp)
+			check.recordPkgName(e.Sel, pkg)
 			// Simplified version of the code for *ast.Idents:
 			// - imported objects are always fully initialized
 			switch exp := exp.(type) {
@@ -318,6 +326,9 @@ func (check *Checker) selector(x *operand, e *ast.SelectorExpr) {
 	}
 
 	obj, index, indirect = LookupFieldOrMethod(x.typ, x.mode == variable, check.pkg, sel)
+	if check.tracing() {
+		check.trace(e.Sel.Pos(), "selector %s on %s: %v (index %v, indirect %v)", sel, x.typ, obj, index, indirect)
+	}
 	if obj == nil {
 		switch {
 		case index != nil:
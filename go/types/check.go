@@ -74,6 +74,8 @@ type Checker struct {
 	// maps and lists are allocated on demand)
 	files            []*ast.File                       // package files
 	unusedDotImports map[*Scope]map[*Package]token.Pos // positions of unused dot-imported packages for each file scope
+	dotImportMap     map[dotImportKey]*PkgName         // maps dot-imported objects to the package they were imported through
+	fileImports      []fileImport                      // imports of all package files, in source order
 
 	firstErr error                 // first error encountered
 	methods  map[string][]*Func    // maps type names to associated methods
@@ -89,6 +91,19 @@ type Checker struct {
 	indent int // indentation for tracing
 }
 
+// A fileImport describes an import declared in the given file and file scope.
+type fileImport struct {
+	file  *ast.File
+	scope *Scope
+	obj   *PkgName
+}
+
+// A dotImportKey describes a dot-imported object in the given file scope.
+type dotImportKey struct {
+	scope *Scope
+	obj   Object
+}
+
 // addUnusedImport adds the position of a dot-imported package
 // pkg to the map of dot imports for the given file scope.
 func (check *Checker) addUnusedDotImport(scope *Scope, pkg *Package, pos token.Pos) {
@@ -176,6 +191,8 @@ func (check *Checker) initFiles(files []*ast.File) {
 	// start with a clean slate (check.Files may be called multiple times)
 	check.files = nil
 	check.unusedDotImports = nil
+	check.dotImportMap = nil
+	check.fileImports = nil
 
 	check.firstErr = nil
 	check.methods = nil
@@ -185,11 +202,20 @@ func (check *Checker) initFiles(files []*ast.File) {
 
 	// determine package name and collect valid files
 	pkg := check.pkg
+	if pkg.name == "" {
+		if name := check.conf.PackageName; name == "_" {
+			check.errorf(token.NoPos, "invalid package name _")
+		} else {
+			pkg.name = name
+		}
+	}
+	var first *ast.File // file that determined the package name, if any
 	for _, file := range files {
 		switch name := file.Name.Name; pkg.name {
 		case "":
 			if name != "_" {
 				pkg.name = name
+				first = file
 			} else {
 				check.errorf(file.Name.Pos(), "invalid package name _")
 			}
@@ -199,7 +225,14 @@ func (check *Checker) initFiles(files []*ast.File) {
 			check.files = append(check.files, file)
 
 		default:
-			check.errorf(file.Package, "package %s; expected %s", name, pkg.name)
+			switch {
+			case name == pkg.name+"_test":
+				check.errorf(file.Package, "package %s; expected %s (external test files must be checked as a separate package)", name, pkg.name)
+			case first != nil && first.Pos().IsValid():
+				check.errorf(file.Package, "package %s; expected %s (as declared in %s)", name, pkg.name, check.fset.File(first.Pos()).Name())
+			default:
+				check.errorf(file.Package, "package %s; expected %s", name, pkg.name)
+			}
 			// ignore this file
 		}
 	}
@@ -220,19 +253,38 @@ func (check *Checker) handleBailout(err *error) {
 }
 
 // Files checks the provided files as part of the checker's package.
+//
+// The files need not have been parsed with the same parser.Mode: the
+// checker ignores comments and the object resolution performed by the
+// parser (ast.File.Scope, ast.Ident.Obj), and relies on the file set
+// only for positions. Files parsed with parser.ImportsOnly or
+// parser.PackageClauseOnly are checked as far as their declarations
+// are present.
 func (check *Checker) Files(files []*ast.File) (err error) {
 	defer check.handleBailout(&err)
 
 	check.initFiles(files)
 
+	if check.conf.ReplaceDeclarations {
+		check.removeRedeclared()
+	}
+
 	check.collectObjects()
 
 	check.packageObjects(check.resolveOrder())
 
 	check.functionBodies()
 
+	// The file scopes remain in the order in which the files were
+	// provided; only the scopes nested within each file are sorted.
+	for _, s := range check.pkg.scope.children {
+		s.sortChildren()
+	}
+
 	check.initOrder()
 
+	check.recordFileImports()
+
 	check.unusedImports()
 
 	// perform delayed checks
@@ -246,8 +298,32 @@ func (check *Checker) Files(files []*ast.File) (err error) {
 	return
 }
 
+func (check *Checker) recordFileImports() {
+	m := check.FileImports
+	if m == nil {
+		return // nothing to do
+	}
+
+	for _, imp := range check.fileImports {
+		fi := m[imp.file]
+		if fi == nil {
+			fi = new(FileImports)
+			m[imp.file] = fi
+		}
+		fi.Declared = append(fi.Declared, imp.obj)
+		used := imp.obj.used
+		if imp.obj.name == "." {
+			_, unused := check.unusedDotImports[imp.scope][imp.obj.imported]
+			used = !unused
+		}
+		if used {
+			fi.Used = append(fi.Used, imp.obj)
+		}
+	}
+}
+
 func (check *Checker) recordUntyped() {
-	if !debug && check.Types == nil {
+	if !debug && !check.recordTypes() {
 		return // nothing to do
 	}
 
@@ -274,6 +350,25 @@ func (check *Checker) recordTypeAndValue(x ast.Expr, mode operandMode, typ Type,
 	if m := check.Types; m != nil {
 		m[x] = TypeAndValue{mode, typ, val}
 	}
+	if o := check.conf.Observer; o != nil {
+		o.OnType(x, TypeAndValue{mode, typ, val})
+	}
+}
+
+// recordTypes reports whether the types of expressions are recorded,
+// in Info.Types or via Config.Observer. It permits skipping work if not.
+func (check *Checker) recordTypes() bool {
+	return check.Types != nil || check.conf.Observer != nil
+}
+
+func (check *Checker) recordUndeclared(id *ast.Ident) {
+	assert(id != nil)
+	if m := check.Types; m != nil {
+		m[id] = TypeAndValue{invalid, Typ[Invalid], nil}
+	}
+	if o := check.conf.Observer; o != nil {
+		o.OnType(id, TypeAndValue{invalid, Typ[Invalid], nil})
+	}
 }
 
 func (check *Checker) recordBuiltinType(f ast.Expr, sig *Signature) {
@@ -300,6 +395,26 @@ func (check *Checker) recordCommaOkTypes(x ast.Expr, a [2]Type) {
 		return
 	}
 	assert(isTyped(a[0]) && isTyped(a[1]) && isBoolean(a[1]))
+	if o := check.conf.Observer; o != nil {
+		// Comma-ok expressions are map index expressions,
+		// type assertions, and receive operations.
+		mode := commaok
+		if _, ok := unparen(x).(*ast.IndexExpr); ok {
+			mode = mapindex
+		}
+		for e := x; ; {
+			pos := e.Pos()
+			o.OnType(e, TypeAndValue{mode, NewTuple(
+				NewVar(pos, check.pkg, "", a[0]),
+				NewVar(pos, check.pkg, "", a[1]),
+			), nil})
+			p, _ := e.(*ast.ParenExpr)
+			if p == nil {
+				break
+			}
+			e = p.X
+		}
+	}
 	if m := check.Types; m != nil {
 		for {
 			tv := m[x]
@@ -325,6 +440,9 @@ func (check *Checker) recordDef(id *ast.Ident, obj Object) {
 	if m := check.Defs; m != nil {
 		m[id] = obj
 	}
+	if o := check.conf.Observer; o != nil {
+		o.OnDef(id, obj)
+	}
 }
 
 func (check *Checker) recordUse(id *ast.Ident, obj Object) {
@@ -333,6 +451,25 @@ func (check *Checker) recordUse(id *ast.Ident, obj Object) {
 	if m := check.Uses; m != nil {
 		m[id] = obj
 	}
+	if o := check.conf.Observer; o != nil {
+		o.OnUse(id, obj)
+	}
+}
+
+func (check *Checker) recordPkgName(id *ast.Ident, pkg *PkgName) {
+	assert(id != nil)
+	assert(pkg != nil)
+	if m := check.PkgNames; m != nil {
+		m[id] = pkg
+	}
+}
+
+func (check *Checker) recordDotImport(id *ast.Ident, pkg *Package) {
+	assert(id != nil)
+	assert(pkg != nil)
+	if m := check.DotImports; m != nil {
+		m[id] = pkg
+	}
 }
 
 func (check *Checker) recordImplicit(node ast.Node, obj Object) {
@@ -341,6 +478,9 @@ func (check *Checker) recordImplicit(node ast.Node, obj Object) {
 	if m := check.Implicits; m != nil {
 		m[node] = obj
 	}
+	if o := check.conf.Observer; o != nil {
+		o.OnImplicit(node, obj)
+	}
 }
 
 func (check *Checker) recordSelection(x *ast.SelectorExpr, kind SelectionKind, recv Type, obj Object, index []int, indirect bool) {
@@ -350,12 +490,75 @@ func (check *Checker) recordSelection(x *ast.SelectorExpr, kind SelectionKind, r
 	if m := check.Selections; m != nil {
 		m[x] = &Selection{kind, recv, obj, index, indirect}
 	}
+	if o := check.conf.Observer; o != nil {
+		o.OnSelection(x, &Selection{kind, recv, obj, index, indirect})
+	}
+}
+
+func (check *Checker) recordConversion(x ast.Expr, from, to Type) {
+	assert(x != nil)
+	assert(from != nil && to != nil)
+	if m := check.Conversions; m != nil {
+		m[x] = [2]Type{from, to}
+	}
+}
+
+func (check *Checker) recordUntypedConversion(x ast.Expr, from, to Type) {
+	assert(x != nil)
+	assert(isUntyped(from) && isTyped(to))
+	if m := check.UntypedConversions; m != nil {
+		m[x] = [2]Type{from, to}
+	}
+}
+
+func (check *Checker) recordAssertion(x ast.Expr, from, to Type) {
+	assert(x != nil && from != nil && to != nil)
+	if m := check.Assertions; m != nil {
+		m[x] = struct{ From, To Type }{from, to}
+	}
+}
+
+func (check *Checker) recordRedundantConversion(call *ast.CallExpr) {
+	assert(call != nil)
+	if m := check.RedundantConversions; m != nil {
+		m[call] = true
+	}
+}
+
+func (check *Checker) recordCallKind(call *ast.CallExpr, kind CallKind) {
+	assert(call != nil)
+	if m := check.CallKind; m != nil {
+		m[call] = kind
+	}
+}
+
+func (check *Checker) recordCompositeLitType(x ast.Expr, typ Type) {
+	assert(x != nil && typ != nil)
+	if m := check.CompositeLitTypes; m != nil {
+		m[x] = typ
+	}
+}
+
+func (check *Checker) recordUnusedResults(call *ast.CallExpr, unused []bool) {
+	assert(call != nil)
+	if m := check.UnusedResults; m != nil {
+		for _, u := range unused {
+			if u {
+				m[call] = unused
+				return
+			}
+		}
+	}
 }
 
 func (check *Checker) recordScope(node ast.Node, scope *Scope) {
 	assert(node != nil)
 	assert(scope != nil)
+	scope.pos = node.Pos()
 	if m := check.Scopes; m != nil {
 		m[node] = scope
 	}
+	if o := check.conf.Observer; o != nil {
+		o.OnScope(node, scope)
+	}
 }
@@ -0,0 +1,144 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements SelectorCandidates.
+
+package types
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+)
+
+// SelectorCandidates returns the objects that may be selected by the
+// selector expression sel, whose operand sel.X is evaluated in scope as
+// for EvalNode. The selector identifier sel.Sel is ignored; it may be a
+// placeholder (such as the "_" inserted by the parser for an incomplete
+// expression "x."). This is the computation underlying editor completion.
+//
+// If sel.X denotes an imported package, the result contains the package's
+// exported members. If sel.X denotes a type T, the result contains the
+// methods of the method set of T (the methods usable in a method expression
+// T.m). Otherwise the result contains the fields and methods that
+// LookupFieldOrMethod finds for the type of sel.X; methods with pointer
+// receivers are only included if sel.X is addressable or a pointer, and
+// ambiguous selectors are excluded. Unexported fields and methods are only
+// included if they belong to pkg.
+//
+// The result is sorted by name. An error is returned if the scope is
+// incorrect or if sel.X cannot be evaluated in the scope.
+func SelectorCandidates(fset *token.FileSet, sel *ast.SelectorExpr, pkg *Package, scope *Scope) (list []Object, err error) {
+	scope, err = evalScope(pkg, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// qualified identifiers
+	if ident, _ := sel.X.(*ast.Ident); ident != nil {
+		if _, obj := scope.LookupParent(ident.Name); obj != nil {
+			if pname, _ := obj.(*PkgName); pname != nil {
+				s := pname.imported.scope
+				for _, name := range s.Names() {
+					if obj := s.Lookup(name); obj.Exported() {
+						list = append(list, obj)
+					}
+				}
+				return list, nil
+			}
+		}
+	}
+
+	// initialize checker
+	check := NewChecker(nil, fset, pkg, nil)
+	check.scope = scope
+	defer check.handleBailout(&err)
+
+	// evaluate operand
+	var x operand
+	check.exprOrType(&x, sel.X)
+
+	// method expressions
+	if x.mode == typexpr {
+		mset := NewMethodSet(x.typ)
+		for i, n := 0, mset.Len(); i < n; i++ {
+			if obj := mset.At(i).obj; obj.Exported() || obj.Pkg() == pkg {
+				list = append(list, obj)
+			}
+		}
+		sort.Sort(byObjectName(list))
+		return list, nil
+	}
+
+	// fields and methods
+	var candidates []Object
+	seen := make(map[string]bool)
+	for _, obj := range selectable(x.typ, nil) {
+		if !obj.Exported() && obj.Pkg() != pkg {
+			continue
+		}
+		if id := obj.Id(); !seen[id] {
+			seen[id] = true
+			candidates = append(candidates, obj)
+		}
+	}
+	for _, c := range candidates {
+		if obj, _, _ := LookupFieldOrMethod(x.typ, x.mode == variable, c.Pkg(), c.Name()); obj != nil {
+			list = append(list, obj)
+		}
+	}
+	sort.Sort(byObjectName(list))
+	return list, nil
+}
+
+// selectable returns all fields and methods that may be selected,
+// at any depth, from a value of type T; the result may contain
+// objects that are not accessible because of shadowing, ambiguity,
+// or missing addressability. The seen map guards against cycles
+// through embedded named types.
+func selectable(T Type, seen map[*Named]bool) (list []Object) {
+	T, _ = deref(T)
+
+	if t, _ := T.(*Named); t != nil {
+		if seen[t] {
+			return nil
+		}
+		if seen == nil {
+			seen = make(map[*Named]bool)
+		}
+		seen[t] = true
+		for _, m := range t.methods {
+			list = append(list, m)
+		}
+	}
+
+	switch t := T.Underlying().(type) {
+	case *Struct:
+		for _, f := range t.fields {
+			list = append(list, f)
+			if f.anonymous {
+				list = append(list, selectable(f.typ, seen)...)
+			}
+		}
+	case *Interface:
+		for _, m := range t.allMethods {
+			list = append(list, m)
+		}
+	}
+	return
+}
+
+// byObjectName sorts objects by name; unexported objects with the same
+// name but from different packages are ordered by package path.
+type byObjectName []Object
+
+func (a byObjectName) Len() int { return len(a) }
+func (a byObjectName) Less(i, j int) bool {
+	x, y := a[i], a[j]
+	if x.Name() != y.Name() {
+		return x.Name() < y.Name()
+	}
+	return x.Id() < y.Id()
+}
+func (a byObjectName) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
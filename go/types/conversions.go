@@ -20,6 +20,8 @@ func (check *Checker) conversion(x *operand, T Type) {
 		switch t := T.Underlying().(*Basic); {
 		case representableConst(x.val, check.conf, t.kind, &x.val):
 			ok = true
+			// the value of an untyped argument may have been rounded
+			check.updateExprVal(x.expr, x.val)
 		case x.isInteger() && isString(t):
 			codepoint := int64(-1)
 			if i, ok := exact.Int64Val(x.val); ok {
@@ -38,9 +40,21 @@ func (check *Checker) conversion(x *operand, T Type) {
 	}
 
 	if !ok {
-		check.errorf(x.pos(), "cannot convert %s to %s", x, T)
-		x.mode = invalid
-		return
+		if check.conf.LenientUnsafe && (isUnsafePointer(x.typ) || isUnsafePointer(T)) {
+			// report error but assume the conversion is valid
+			check.softErrorf(x.pos(), "cannot convert %s to %s", x, T)
+			x.mode = value
+		} else {
+			err := Error{Fset: check.fset, Pos: x.pos(), Msg: check.sprintf("cannot convert %s to %s", x, T)}
+			if constArg && isConstType(T) {
+				// constant not representable by T
+				err.Value = x.val
+				err.Target = T
+			}
+			check.report(err)
+			x.mode = invalid
+			return
+		}
 	}
 
 	// The conversion argument types are final. For untyped values the
@@ -65,7 +79,7 @@ func (check *Checker) conversion(x *operand, T Type) {
 
 func (x *operand) convertibleTo(conf *Config, T Type) bool {
 	// "x is assignable to T"
-	if x.assignableTo(conf, T) {
+	if x.assignableTo(conf, T, nil) {
 		return true
 	}
 
@@ -73,6 +87,9 @@ func (x *operand) convertibleTo(conf *Config, T Type) bool {
 	V := x.typ
 	Vu := V.Underlying()
 	Tu := T.Underlying()
+	if Vu == nil || Tu == nil {
+		return false // named type not set up yet (see NewNamed)
+	}
 	if Identical(Vu, Tu) {
 		return true
 	}
@@ -31,12 +31,21 @@ func (check *Checker) declare(scope *Scope, id *ast.Ident, obj Object) {
 			check.reportAltDecl(alt)
 			return
 		}
+		check.reportShadowing(obj.Pos(), obj.Name())
 	}
 	if id != nil {
 		check.recordDef(id, obj)
 	}
 }
 
+// reportShadowing reports a warning if Config.ReportShadowing is set
+// and the declaration of name at pos shadows a predeclared object.
+func (check *Checker) reportShadowing(pos token.Pos, name string) {
+	if check.conf.ReportShadowing && name != "_" && Universe.Lookup(name) != nil {
+		check.warnf(SeverityWarning, pos, "declaration of %s shadows predeclared identifier", name)
+	}
+}
+
 // objDecl type-checks the declaration of obj in its respective (file) context.
 // See check.typ for the details on def and path.
 func (check *Checker) objDecl(obj Object, def *Named, path []*TypeName) {
@@ -44,7 +53,7 @@ func (check *Checker) objDecl(obj Object, def *Named, path []*TypeName) {
 		return // already checked - nothing to do
 	}
 
-	if trace {
+	if check.tracing() {
 		check.trace(obj.Pos(), "-- declaring %s", obj.Name())
 		check.indent++
 		defer func() {
@@ -152,6 +161,13 @@ func (check *Checker) varDecl(obj *Var, lhs []*Var, typ, init ast.Expr) {
 		return
 	}
 
+	if typ == nil {
+		obj.inferred = true
+		for _, lhs := range lhs {
+			lhs.inferred = true
+		}
+	}
+
 	if lhs == nil || len(lhs) == 1 {
 		assert(lhs == nil || lhs[0] == obj)
 		var x operand
@@ -305,7 +321,7 @@ func (check *Checker) funcDecl(obj *Func, decl *declInfo) {
 
 	// function body must be type-checked after global declarations
 	// (functions implemented elsewhere have no body)
-	if !check.conf.IgnoreFuncBodies && fdecl.Body != nil {
+	if !check.conf.IgnoreFuncBodies && !check.conf.ExportedOnly && fdecl.Body != nil {
 		check.later(obj.name, decl, sig, fdecl.Body)
 	}
 }
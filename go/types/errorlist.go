@@ -0,0 +1,105 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements ErrorLists.
+
+package types
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+)
+
+// An ErrorList is a list of type-checking errors, usually of type
+// Error. Its Add method
+// may be used as Config.Error callback to collect all errors:
+//
+//	var errors types.ErrorList
+//	conf := types.Config{Error: errors.Add}
+//	conf.Check(path, fset, files, nil)
+//	return errors.Err()
+//
+// The zero value for ErrorList is an empty ErrorList ready to use.
+type ErrorList []error
+
+// Add adds err to the list.
+func (p *ErrorList) Add(err error) {
+	*p = append(*p, err)
+}
+
+// ErrorList implements the sort Interface.
+func (p ErrorList) Len() int      { return len(p) }
+func (p ErrorList) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+
+func (p ErrorList) Less(i, j int) bool {
+	e, f := errorPos(p[i]), errorPos(p[j])
+	if e.Filename != f.Filename {
+		return e.Filename < f.Filename
+	}
+	if e.Line != f.Line {
+		return e.Line < f.Line
+	}
+	if e.Column != f.Column {
+		return e.Column < f.Column
+	}
+	return errorMsg(p[i]) < errorMsg(p[j])
+}
+
+// Sort sorts the list in source order (by filename, line, and column)
+// and removes all but the first of multiple errors with the same
+// position and message. Errors that are not of type Error have no
+// position and are sorted before all others.
+func (p *ErrorList) Sort() {
+	sort.Stable(*p)
+	list := (*p)[:0] // reuse underlying array
+	for i, e := range *p {
+		if i > 0 {
+			last := list[len(list)-1]
+			if errorPos(e) == errorPos(last) && errorMsg(e) == errorMsg(last) {
+				continue
+			}
+		}
+		list = append(list, e)
+	}
+	*p = list
+}
+
+// Error returns a string describing the first error in the list
+// and the number of remaining errors, if any.
+func (p ErrorList) Error() string {
+	switch len(p) {
+	case 0:
+		return "no errors"
+	case 1:
+		return p[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", p[0].Error(), len(p)-1)
+}
+
+// Err sorts the list and returns it as an error,
+// or nil if the list is empty.
+func (p *ErrorList) Err() error {
+	if len(*p) == 0 {
+		return nil
+	}
+	p.Sort()
+	return *p
+}
+
+// errorPos returns the source position of err, if any.
+func errorPos(err error) token.Position {
+	if err, ok := err.(Error); ok && err.Fset != nil {
+		return err.Fset.Position(err.Pos)
+	}
+	return token.Position{}
+}
+
+// errorMsg returns the message of err without position.
+func errorMsg(err error) string {
+	if err, ok := err.(Error); ok {
+		return err.Msg
+	}
+	return err.Error()
+}
@@ -10,6 +10,8 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"io"
+	"os"
 	"strings"
 )
 
@@ -46,8 +48,22 @@ func (check *Checker) sprintf(format string, args ...interface{}) string {
 	return fmt.Sprintf(format, args...)
 }
 
+// tracing reports whether tracing is enabled, either
+// via the trace constant or via Config.Trace.
+func (check *Checker) tracing() bool {
+	return trace || check.conf.Trace != nil
+}
+
+// traceWriter returns the destination of trace output.
+func (check *Checker) traceWriter() io.Writer {
+	if w := check.conf.Trace; w != nil {
+		return w
+	}
+	return os.Stdout
+}
+
 func (check *Checker) trace(pos token.Pos, format string, args ...interface{}) {
-	fmt.Printf("%s:\t%s%s\n",
+	fmt.Fprintf(check.traceWriter(), "%s:\t%s%s\n",
 		check.fset.Position(pos),
 		strings.Repeat(".  ", check.indent),
 		check.sprintf(format, args...),
@@ -60,7 +76,11 @@ func (check *Checker) dump(format string, args ...interface{}) {
 }
 
 func (check *Checker) err(pos token.Pos, msg string, soft bool) {
-	err := Error{check.fset, pos, msg, soft}
+	check.report(Error{Fset: check.fset, Pos: pos, Msg: msg, Soft: soft})
+}
+
+// report reports the error err, which must be of SeverityError.
+func (check *Checker) report(err Error) {
 	if check.firstErr == nil {
 		check.firstErr = err
 	}
@@ -83,6 +103,16 @@ func (check *Checker) softErrorf(pos token.Pos, format string, args ...interface
 	check.err(pos, check.sprintf(format, args...), true)
 }
 
+// warnf reports an advisory diagnostic of the given severity. Unlike
+// errors, it doesn't affect the outcome of type-checking; it is dropped
+// if there is no Config.Error function.
+func (check *Checker) warnf(severity Severity, pos token.Pos, format string, args ...interface{}) {
+	assert(severity != SeverityError)
+	if f := check.conf.Error; f != nil {
+		f(Error{Fset: check.fset, Pos: pos, Msg: check.sprintf(format, args...), Soft: true, Severity: severity})
+	}
+}
+
 func (check *Checker) invalidAST(pos token.Pos, format string, args ...interface{}) {
 	check.errorf(pos, "invalid AST: "+format, args...)
 }
@@ -71,18 +71,9 @@ func Eval(str string, pkg *Package, scope *Scope) (typ Type, val exact.Value, er
 // if the node cannot be evaluated in the scope.
 //
 func EvalNode(fset *token.FileSet, node ast.Expr, pkg *Package, scope *Scope) (typ Type, val exact.Value, err error) {
-	// verify package/scope relationship
-	if pkg == nil {
-		scope = Universe
-	} else {
-		s := scope
-		for s != nil && s != pkg.scope {
-			s = s.parent
-		}
-		// s == nil || s == pkg.scope
-		if s == nil {
-			return nil, nil, fmt.Errorf("scope does not belong to package %s", pkg.name)
-		}
+	scope, err = evalScope(pkg, scope)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// initialize checker
@@ -107,3 +98,20 @@ func EvalNode(fset *token.FileSet, node ast.Expr, pkg *Package, scope *Scope) (t
 
 	return
 }
+
+// evalScope verifies the package/scope relationship for an evaluation
+// in scope and returns the scope to use (Universe if pkg == nil).
+func evalScope(pkg *Package, scope *Scope) (*Scope, error) {
+	if pkg == nil {
+		return Universe, nil
+	}
+	s := scope
+	for s != nil && s != pkg.scope {
+		s = s.parent
+	}
+	// s == nil || s == pkg.scope
+	if s == nil {
+		return nil, fmt.Errorf("scope does not belong to package %s", pkg.name)
+	}
+	return scope, nil
+}
@@ -126,7 +126,7 @@ func (check *Checker) unary(x *operand, op token.Token) {
 		// Typed constants must be representable in
 		// their type after each constant operation.
 		if isTyped(typ) {
-			check.representable(x, typ)
+			check.representable(x, x.typ)
 		}
 		return
 	}
@@ -319,9 +319,11 @@ func representableConst(x exact.Value, conf *Config, as BasicKind, rounded *exac
 	return false
 }
 
-// representable checks that a constant operand is representable in the given basic type.
-func (check *Checker) representable(x *operand, typ *Basic) {
+// representable checks that a constant operand is representable in the given
+// target type, which must have a basic underlying type.
+func (check *Checker) representable(x *operand, target Type) {
 	assert(x.mode == constant)
+	typ := target.Underlying().(*Basic)
 	if !representableConst(x.val, check.conf, typ.kind, &x.val) {
 		var msg string
 		if isNumeric(x.typ) && isNumeric(typ) {
@@ -340,7 +342,13 @@ func (check *Checker) representable(x *operand, typ *Basic) {
 		} else {
 			msg = "cannot convert %s to %s"
 		}
-		check.errorf(x.pos(), msg, x, typ)
+		check.report(Error{
+			Fset:   check.fset,
+			Pos:    x.pos(),
+			Msg:    check.sprintf(msg, x, typ),
+			Value:  x.val,
+			Target: target,
+		})
 		x.mode = invalid
 	}
 }
@@ -462,6 +470,16 @@ func (check *Checker) updateExprVal(x ast.Expr, val exact.Value) {
 	}
 }
 
+// defaultUntyped updates the type of the non-constant operand x
+// to its final "materialized" type: the default type if x is untyped.
+func (check *Checker) defaultUntyped(x *operand) {
+	typ := defaultType(x.typ)
+	if isUntyped(x.typ) && isTyped(typ) && x.expr != nil {
+		check.recordUntypedConversion(x.expr, x.typ, typ)
+	}
+	check.updateExprType(x.expr, typ, true)
+}
+
 // convertUntyped attempts to set the type of an untyped value to the target type.
 func (check *Checker) convertUntyped(x *operand, target Type) {
 	if x.mode == invalid || isTyped(x.typ) || target == Typ[Invalid] {
@@ -490,7 +508,7 @@ func (check *Checker) convertUntyped(x *operand, target Type) {
 	switch t := target.Underlying().(type) {
 	case *Basic:
 		if x.mode == constant {
-			check.representable(x, t)
+			check.representable(x, target)
 			if x.mode == invalid {
 				return
 			}
@@ -554,6 +572,9 @@ func (check *Checker) convertUntyped(x *operand, target Type) {
 		goto Error
 	}
 
+	if isTyped(target) && x.expr != nil {
+		check.recordUntypedConversion(x.expr, x.typ, target)
+	}
 	x.typ = target
 	check.updateExprType(x.expr, target, true) // UntypedNils are final
 	return
@@ -567,7 +588,7 @@ func (check *Checker) comparison(x, y *operand, op token.Token) {
 	// spec: "In any comparison, the first operand must be assignable
 	// to the type of the second operand, or vice versa."
 	err := ""
-	if x.assignableTo(check.conf, y.typ) || y.assignableTo(check.conf, x.typ) {
+	if x.assignableTo(check.conf, y.typ, nil) || y.assignableTo(check.conf, x.typ, nil) {
 		defined := false
 		switch op {
 		case token.EQL, token.NEQ:
@@ -606,8 +627,8 @@ func (check *Checker) comparison(x, y *operand, op token.Token) {
 		// time will be materialized. Update the expression trees.
 		// If the current types are untyped, the materialized type
 		// is the respective default type.
-		check.updateExprType(x.expr, defaultType(x.typ), true)
-		check.updateExprType(y.expr, defaultType(y.typ), true)
+		check.defaultUntyped(x)
+		check.defaultUntyped(y)
 	}
 
 	// spec: "Comparison operators compare two operands and yield
@@ -767,8 +788,15 @@ func (check *Checker) binary(x *operand, lhs, rhs ast.Expr, op token.Token) {
 	}
 
 	if (op == token.QUO || op == token.REM) && (x.mode == constant || isInteger(x.typ)) && y.mode == constant && exact.Sign(y.val) == 0 {
-		check.invalidOp(y.pos(), "division by zero")
-		x.mode = invalid
+		// The error is soft: a non-constant result has a well-defined
+		// type and remains a valid operand; a constant result has no
+		// value and becomes invalid, which avoids follow-on errors.
+		check.softErrorf(y.pos(), "invalid operation: division by zero")
+		if x.mode == constant {
+			x.mode = invalid
+		} else {
+			x.mode = value
+		}
 		return
 	}
 
@@ -782,7 +810,7 @@ func (check *Checker) binary(x *operand, lhs, rhs ast.Expr, op token.Token) {
 		// Typed constants must be representable in
 		// their type after each constant operation.
 		if isTyped(typ) {
-			check.representable(x, typ)
+			check.representable(x, x.typ)
 		}
 		return
 	}
@@ -873,6 +901,7 @@ func (check *Checker) indexedElts(elts []ast.Expr, typ Type, length int64) int64
 
 		// check element against composite literal element type
 		var x operand
+		check.recordCompositeLitType(eval, typ)
 		check.exprWithHint(&x, eval, typ)
 		if !check.assignment(&x, typ) && x.mode != invalid {
 			check.errorf(x.pos(), "cannot use %s as %s value in array or slice literal", &x, typ)
@@ -896,7 +925,7 @@ const (
 // If hint != nil, it is the type of a composite literal element.
 //
 func (check *Checker) rawExpr(x *operand, e ast.Expr, hint Type) exprKind {
-	if trace {
+	if check.tracing() {
 		check.trace(e.Pos(), "%s", e)
 		check.indent++
 		defer func() {
@@ -989,7 +1018,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 					// We have an "open" [...]T array type.
 					// Create a new ArrayType with unknown length (-1)
 					// and finish setting it up after analyzing the literal.
-					typ = &Array{len: -1, elem: check.typ(atyp.Elt)}
+					typ = &Array{len: -1, elem: check.typ(atyp.Elt), implicit: true}
 					openArray = true
 				}
 			}
@@ -1035,6 +1064,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 						continue
 					}
 					visited[i] = true
+					check.recordCompositeLitType(kv.Value, fld.typ)
 					check.expr(x, kv.Value)
 					etyp := fld.typ
 					if !check.assignment(x, etyp) {
@@ -1051,6 +1081,9 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 						check.error(kv.Pos(), "mixture of field:value and value elements in struct literal")
 						continue
 					}
+					if i < len(fields) {
+						check.recordCompositeLitType(e, fields[i].typ)
+					}
 					check.expr(x, e)
 					if i >= len(fields) {
 						check.error(x.pos(), "too many values in struct literal")
@@ -1089,6 +1122,8 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 					check.error(e.Pos(), "missing key in map literal")
 					continue
 				}
+				check.recordCompositeLitType(kv.Key, utyp.key)
+				check.recordCompositeLitType(kv.Value, utyp.elem)
 				check.expr(x, kv.Key)
 				if !check.assignment(x, utyp.key) {
 					if x.mode != invalid {
@@ -1331,6 +1366,7 @@ func (check *Checker) exprInternal(x *operand, e ast.Expr, hint Type) exprKind {
 		if T == Typ[Invalid] {
 			goto Error
 		}
+		check.recordAssertion(e, x.typ, T)
 		check.typeAssertion(x.pos(), x, xtyp, T)
 		x.mode = commaok
 		x.typ = T
@@ -1424,11 +1460,17 @@ func (check *Checker) typeAssertion(pos token.Pos, x *operand, xtyp *Interface,
 // If an error occurred, x.mode is set to invalid.
 //
 func (check *Checker) expr(x *operand, e ast.Expr) {
-	check.rawExpr(x, e, nil)
+	check.exprKind(x, e)
+}
+
+// exprKind is like expr but also returns the kind of expression e.
+//
+func (check *Checker) exprKind(x *operand, e ast.Expr) exprKind {
+	kind := check.rawExpr(x, e, nil)
 	var msg string
 	switch x.mode {
 	default:
-		return
+		return kind
 	case novalue:
 		msg = "used as value"
 	case builtin:
@@ -1438,6 +1480,7 @@ func (check *Checker) expr(x *operand, e ast.Expr) {
 	}
 	check.errorf(x.pos(), "%s %s", x, msg)
 	x.mode = invalid
+	return kind
 }
 
 // exprWithHint typechecks expression e and initializes x with the expression value.
@@ -12,6 +12,10 @@ import (
 )
 
 // ExprString returns the (possibly simplified) string representation for x.
+// Except for function literals, whose bodies are omitted, the result is
+// valid Go syntax which parses to an expression equivalent to x, provided
+// x was generated by a Go parser; parentheses and struct tags are preserved
+// but comments are not. Thus the result may be used as a key for x.
 func ExprString(x ast.Expr) string {
 	var buf bytes.Buffer
 	WriteExpr(&buf, x)
@@ -27,7 +31,7 @@ func WriteExpr(buf *bytes.Buffer, x ast.Expr) {
 
 	switch x := x.(type) {
 	default:
-		buf.WriteString("(bad expr)") // nil, ast.BadExpr, ast.KeyValueExpr
+		buf.WriteString("(bad expr)") // nil, ast.BadExpr
 
 	case *ast.Ident:
 		buf.WriteString(x.Name)
@@ -47,9 +51,18 @@ func WriteExpr(buf *bytes.Buffer, x ast.Expr) {
 		buf.WriteString(" literal)") // simplified
 
 	case *ast.CompositeLit:
-		buf.WriteByte('(')
-		WriteExpr(buf, x.Type)
-		buf.WriteString(" literal)") // simplified
+		// the type of a nested literal may be elided
+		if x.Type != nil {
+			WriteExpr(buf, x.Type)
+		}
+		buf.WriteByte('{')
+		writeExprList(buf, x.Elts)
+		buf.WriteByte('}')
+
+	case *ast.KeyValueExpr:
+		WriteExpr(buf, x.Key)
+		buf.WriteString(": ")
+		WriteExpr(buf, x.Value)
 
 	case *ast.ParenExpr:
 		buf.WriteByte('(')
@@ -88,18 +101,17 @@ func WriteExpr(buf *bytes.Buffer, x ast.Expr) {
 	case *ast.TypeAssertExpr:
 		WriteExpr(buf, x.X)
 		buf.WriteString(".(")
-		WriteExpr(buf, x.Type)
+		if x.Type != nil {
+			WriteExpr(buf, x.Type)
+		} else {
+			buf.WriteString("type") // type switch guard
+		}
 		buf.WriteByte(')')
 
 	case *ast.CallExpr:
 		WriteExpr(buf, x.Fun)
 		buf.WriteByte('(')
-		for i, arg := range x.Args {
-			if i > 0 {
-				buf.WriteString(", ")
-			}
-			WriteExpr(buf, arg)
-		}
+		writeExprList(buf, x.Args)
 		if x.Ellipsis.IsValid() {
 			buf.WriteString("...")
 		}
@@ -111,6 +123,10 @@ func WriteExpr(buf *bytes.Buffer, x ast.Expr) {
 
 	case *ast.UnaryExpr:
 		buf.WriteString(x.Op.String())
+		if _, ok := x.X.(*ast.UnaryExpr); ok {
+			// separate consecutive operators (- -x, not --x)
+			buf.WriteByte(' ')
+		}
 		WriteExpr(buf, x.X)
 
 	case *ast.BinaryExpr:
@@ -215,6 +231,18 @@ func writeFieldList(buf *bytes.Buffer, fields *ast.FieldList, sep string, iface
 
 		WriteExpr(buf, f.Type)
 
-		// ignore tag
+		if f.Tag != nil {
+			buf.WriteByte(' ')
+			buf.WriteString(f.Tag.Value)
+		}
+	}
+}
+
+func writeExprList(buf *bytes.Buffer, list []ast.Expr) {
+	for i, x := range list {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		WriteExpr(buf, x)
 	}
 }
@@ -5,7 +5,9 @@
 package types_test
 
 import (
+	"go/ast"
 	"go/parser"
+	"strings"
 	"testing"
 
 	. "golang.org/x/tools/go/types"
@@ -25,7 +27,15 @@ var testExprs = []testEntry{
 	// func and composite literals
 	{"func(){}", "(func() literal)"},
 	{"func(x int) complex128 {}", "(func(x int) complex128 literal)"},
-	{"[]int{1, 2, 3}", "([]int literal)"},
+	dup("[]int{1, 2, 3}"),
+	dup("[]int{}"),
+	dup("[...]string{2: \"foo\"}"),
+	dup("map[string][]int{\"a\": {1}, \"b\": nil}"),
+	dup("[][2]T{{1, 2}, {3, 4}}"),
+	dup("T{f: 1, g: &T{}}"),
+	dup("p.T{}"),
+	dup("struct{x int}{1}"),
+	{"T{\n\t1,\n\t2,\n}", "T{1, 2}"},
 
 	// non-type expressions
 	dup("(x)"),
@@ -40,12 +50,17 @@ var testExprs = []testEntry{
 	dup("s[i:j:k]"),
 
 	dup("x.(T)"),
+	dup("(x.(int))"),
+	dup("(x.(int)).f"),
+	dup("x.(*T)"),
+	dup("x.(p.T)"),
 
 	dup("x.([10]int)"),
 	dup("x.([...]int)"),
 
 	dup("x.(struct{})"),
 	dup("x.(struct{x int; y, z float32; E})"),
+	dup("x.(struct{x int \"tag\"; *E `json:\"e\"`})"),
 
 	dup("x.(func())"),
 	dup("x.(func(x int))"),
@@ -76,6 +91,14 @@ var testExprs = []testEntry{
 
 	dup("*x"),
 	dup("&x"),
+	dup("-x"),
+	dup("- -x"),
+	dup("+ +x"),
+	dup("! !x"),
+	dup("& ^x"),
+	dup("<- <-ch"),
+	dup("-*x"),
+	dup("x - -y"),
 	dup("x + y"),
 	dup("x + y << (2 * s)"),
 }
@@ -92,3 +115,29 @@ func TestExprString(t *testing.T) {
 		}
 	}
 }
+
+func TestExprStringRoundTrip(t *testing.T) {
+	for _, test := range testExprs {
+		if strings.HasSuffix(test.str, " literal)") {
+			continue // function literal bodies are not printed
+		}
+		x, err := parser.ParseExpr(test.str)
+		if err != nil {
+			t.Errorf("%s: %s", test.str, err)
+			continue
+		}
+		if got := ExprString(x); got != test.str {
+			t.Errorf("%s: got %s after round trip", test.str, got)
+		}
+	}
+
+	// type switch guards are not expressions on their own
+	f, err := parser.ParseFile(fset, "guard.go", "package p; func _() { switch (x).(type) {} }", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	guard := f.Decls[0].(*ast.FuncDecl).Body.List[0].(*ast.TypeSwitchStmt).Assign.(*ast.ExprStmt).X
+	if got, want := ExprString(guard), "(x).(type)"; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements FreeVars.
+
+package types
+
+import "go/ast"
+
+// FreeVars returns the variables captured by the function literal lit,
+// that is, the local variables (including parameters and results) of
+// enclosing functions that are referred to in the body of lit, including
+// references in function literals nested within lit. Package-level
+// variables and struct fields are not free variables. The variables are
+// listed in the order of their first reference in lit.
+//
+// The type information for the package containing lit must be provided
+// by info, with the Uses and Scopes maps populated. The result is nil if
+// there is no scope recorded for lit.
+func FreeVars(info *Info, lit *ast.FuncLit) []*Var {
+	scope := info.Scopes[lit.Type]
+	if scope == nil {
+		return nil
+	}
+
+	var list []*Var
+	seen := make(map[*Var]bool)
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		id, _ := n.(*ast.Ident)
+		if id == nil {
+			return true
+		}
+		v, _ := info.Uses[id].(*Var)
+		if v == nil || v.isField || seen[v] {
+			return true
+		}
+		// v is free if it is declared in a local scope outside lit
+		if p := v.parent; p != nil && p.parent != Universe && !encloses(scope, p) {
+			seen[v] = true
+			list = append(list, v)
+		}
+		return true
+	})
+	return list
+}
@@ -15,6 +15,11 @@ func (check *Checker) initOrder() {
 	// built from several calls to (*Checker).Files.  Clear it.
 	check.Info.InitOrder = check.Info.InitOrder[:0]
 
+	// Not all package-level objects are type-checked in ExportedOnly mode.
+	if check.conf.ExportedOnly || check.conf.NoInitOrder {
+		return
+	}
+
 	// compute the object dependency graph and
 	// initialize a priority queue with the list
 	// of graph nodes
@@ -25,7 +30,7 @@ func (check *Checker) initOrder() {
 	if debug {
 		fmt.Printf("package %s: object dependency graph\n", check.pkg.Name())
 		for _, n := range pq {
-			for _, o := range n.out {
+			for o := range n.out {
 				fmt.Printf("\t%s -> %s\n", n.obj.Name(), o.obj.Name())
 			}
 		}
@@ -39,7 +44,6 @@ func (check *Checker) initOrder() {
 	// In a valid Go program, those nodes always have zero dependencies (after
 	// removing all incoming dependencies), otherwise there are initialization
 	// cycles.
-	mark := 0
 	emitted := make(map[*declInfo]bool)
 	for len(pq) > 0 {
 		// get the next node
@@ -47,10 +51,11 @@ func (check *Checker) initOrder() {
 
 		// if n still depends on other nodes, we have a cycle
 		if n.in > 0 {
-			mark++ // mark nodes using a different value each time
-			cycle := findPath(n, n, mark)
-			if i := valIndex(cycle); i >= 0 {
-				check.reportCycle(cycle, i)
+			cycle := findPath(check.objMap, n.obj, n.obj, make(map[Object]bool))
+			// If n.obj is not part of the cycle, it depends on a
+			// cycle that was reported before; don't report it again.
+			if cycle != nil {
+				check.reportCycle(cycle)
 			}
 			// ok to continue, but the variable initialization order
 			// will be incorrect at this point since it assumes no
@@ -59,7 +64,7 @@ func (check *Checker) initOrder() {
 
 		// reduce dependency count of all dependent nodes
 		// and update priority queue
-		for _, out := range n.out {
+		for out := range n.out {
 			out.in--
 			heap.Fix(&pq, out.index)
 		}
@@ -97,94 +102,122 @@ func (check *Checker) initOrder() {
 	}
 }
 
-// findPath returns the (reversed) list of nodes z, ... c, b, a,
-// such that there is a path (list of edges) from a to z.
+// findPath returns the (reversed) list of objects []Object{to, ... from}
+// such that there is a path of object dependencies from 'from' to 'to'.
 // If there is no such path, the result is nil.
-// Nodes marked with the value mark are considered "visited";
-// unvisited nodes are marked during the graph search.
-func findPath(a, z *objNode, mark int) []*objNode {
-	if a.mark == mark {
+func findPath(objMap map[Object]*declInfo, from, to Object, visited map[Object]bool) []Object {
+	if visited[from] {
 		return nil // node already seen
 	}
-	a.mark = mark
+	visited[from] = true
 
-	for _, n := range a.out {
-		if n == z {
-			return []*objNode{z}
+	for d := range objMap[from].deps {
+		if d == to {
+			return []Object{d}
 		}
-		if P := findPath(n, z, mark); P != nil {
-			return append(P, n)
+		if P := findPath(objMap, d, to, visited); P != nil {
+			return append(P, d)
 		}
 	}
 
 	return nil
 }
 
-// valIndex returns the index of the first constant or variable in a,
-// if any; or a value < 0.
-func valIndex(a []*objNode) int {
-	for i, n := range a {
-		switch n.obj.(type) {
-		case *Const, *Var:
-			return i
-		}
-	}
-	return -1
-}
-
-// reportCycle reports an error for the cycle starting at i.
-func (check *Checker) reportCycle(cycle []*objNode, i int) {
-	obj := cycle[i].obj
+// reportCycle reports an error for the given cycle.
+func (check *Checker) reportCycle(cycle []Object) {
+	obj := cycle[0]
 	check.errorf(obj.Pos(), "initialization cycle for %s", obj.Name())
-	// print cycle
-	for _ = range cycle {
+	// subtle loop: print cycle[i] for i = 0, n-1, n-2, ... 1 for len(cycle) = n
+	for i := len(cycle) - 1; i >= 0; i-- {
 		check.errorf(obj.Pos(), "\t%s refers to", obj.Name()) // secondary error, \t indented
-		i++
-		if i >= len(cycle) {
-			i = 0
-		}
-		obj = cycle[i].obj
+		obj = cycle[i]
 	}
 	check.errorf(obj.Pos(), "\t%s", obj.Name())
 }
 
 // An objNode represents a node in the object dependency graph.
 // Each node b in a.out represents an edge a->b indicating that
-// b depends on a.
-// Nodes may be marked for cycle detection. A node n is marked
-// if n.mark corresponds to the current mark value.
+// b depends on a; each node a in b.deps represents the same edge.
 type objNode struct {
-	obj   Object     // object represented by this node
-	in    int        // number of nodes this node depends on
-	out   []*objNode // list of nodes that depend on this node
-	index int        // node index in list of nodes
-	mark  int        // for cycle detection
+	obj   Object  // object represented by this node
+	in    int     // number of nodes this node depends on
+	out   nodeSet // set of nodes that depend on this node
+	deps  nodeSet // set of nodes this node depends on
+	index int     // node index in list of nodes
+}
+
+// A nodeSet is a set of objNodes.
+type nodeSet map[*objNode]bool
+
+func (s *nodeSet) add(n *objNode) {
+	if *s == nil {
+		*s = make(nodeSet)
+	}
+	(*s)[n] = true
 }
 
 // dependencyGraph computes the transposed object dependency graph
-// from the given objMap. The transposed graph is returned as a list
-// of nodes; an edge d->n indicates that node n depends on node d.
+// from the given objMap, with all function nodes removed; the nodes
+// represent constants and variables only. The transposed graph is
+// returned as a list of nodes; an edge d->n indicates that node n
+// depends on node d.
 func dependencyGraph(objMap map[Object]*declInfo) []*objNode {
-	// M maps each object to its corresponding node
+	// M maps each object that may be an initialization
+	// dependency to its corresponding node
 	M := make(map[Object]*objNode, len(objMap))
 	for obj := range objMap {
-		M[obj] = &objNode{obj: obj}
+		switch obj.(type) {
+		case *Const, *Var, *Func:
+			M[obj] = &objNode{obj: obj}
+		}
+	}
+
+	// compute edges for graph M
+	for obj, n := range M {
+		for d := range objMap[obj].deps {
+			if d := M[d]; d != nil { // node n depends on node d
+				d.out.add(n) // add edge d->n
+				n.deps.add(d)
+			}
+		}
 	}
 
-	// G is the graph of nodes n
-	G := make([]*objNode, len(M))
-	i := 0
+	// Remove function nodes and collect the remaining nodes in G.
+	// Functions are not initialized, but their dependencies become
+	// dependencies of the nodes depending on them. Mutually recursive
+	// functions may introduce cycles among themselves which are
+	// permitted; left in the graph such cycles would inflate the
+	// dependency count of variables which in turn would not get
+	// scheduled for initialization in (source) order.
+	var G []*objNode
 	for obj, n := range M {
-		deps := objMap[obj].deps
-		n.in = len(deps)
-		for d := range deps {
-			d := M[d]                // node n depends on node d
-			d.out = append(d.out, n) // add edge d->n
+		if _, ok := obj.(*Func); ok {
+			// connect each dependent p of n with each dependency d of n
+			// and drop n
+			for p := range n.out {
+				for d := range n.deps {
+					// ignore self-cycles
+					if p != n && d != n {
+						d.out.add(p)
+						p.deps.add(d)
+					}
+				}
+			}
+			for p := range n.out {
+				delete(p.deps, n)
+			}
+			for d := range n.deps {
+				delete(d.out, n)
+			}
+		} else {
+			G = append(G, n)
 		}
+	}
 
-		G[i] = n
+	// fill in index and dependency count
+	for i, n := range G {
 		n.index = i
-		i++
+		n.in = len(n.deps)
 	}
 
 	return G
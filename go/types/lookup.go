@@ -6,6 +6,11 @@
 
 package types
 
+import (
+	"fmt"
+	"strings"
+)
+
 // LookupFieldOrMethod looks up a field or method with given package and name
 // in T and returns the corresponding *Var or *Func, an index sequence, and a
 // bool indicating if there were any pointer indirections on the path to the
@@ -224,6 +229,220 @@ func consolidateMultiples(list []embeddedType) []embeddedType {
 	return list[:n]
 }
 
+// Shadowed returns the fields and methods of the types embedded (directly
+// or indirectly) in T that cannot be selected on a value of type T: those
+// that are shadowed by a field or method with the same name at a shallower
+// embedding depth, and those that are ambiguous because the same name
+// appears more than once at the shallowest depth at which it occurs.
+// Fields and methods are reported once each, in order of increasing
+// embedding depth. If T is a pointer, the pointer base type is used.
+func Shadowed(T Type) []Object {
+	// This function follows the structure of lookupFieldOrMethod,
+	// but collects all names at each depth rather than a single one.
+
+	typ, _ := deref(T)
+	named, _ := typ.(*Named)
+	current := []embeddedType{{named, nil, false, false}}
+
+	var seen map[*Named]bool
+	visible := make(map[string]bool) // ids of fields and methods selectable on T
+	var list []Object
+
+	for len(current) > 0 {
+		var next []embeddedType // embedded types found at current depth
+
+		var ids []string                   // ids found at current depth, in order
+		found := make(map[string][]Object) // objects found at current depth, by id
+		count := make(map[string]int)      // number of occurrences by id
+		add := func(obj Object, multiples bool) {
+			if obj.Name() == "_" {
+				return // blank fields and methods are never selectable
+			}
+			id := obj.Id()
+			if count[id] == 0 {
+				ids = append(ids, id)
+			}
+			found[id] = append(found[id], obj)
+			count[id]++
+			if multiples {
+				count[id]++
+			}
+		}
+
+		for _, e := range current {
+			typ := typ
+			if e.typ != nil {
+				if seen[e.typ] {
+					continue // reported at a shallower depth, if at all
+				}
+				if seen == nil {
+					seen = make(map[*Named]bool)
+				}
+				seen[e.typ] = true
+				for _, m := range e.typ.methods {
+					add(m, e.multiples)
+				}
+				typ = e.typ.underlying
+			}
+
+			switch t := typ.(type) {
+			case *Struct:
+				for i, f := range t.fields {
+					add(f, e.multiples)
+					if f.anonymous {
+						typ, isPtr := deref(f.typ)
+						if t, _ := typ.(*Named); t != nil {
+							next = append(next, embeddedType{t, concat(e.index, i), e.indirect || isPtr, e.multiples})
+						}
+					}
+				}
+			case *Interface:
+				for _, m := range t.allMethods {
+					add(m, e.multiples)
+				}
+			}
+		}
+
+		for _, id := range ids {
+			if visible[id] || count[id] > 1 {
+				list = append(list, found[id]...)
+			}
+			visible[id] = true
+		}
+
+		current = consolidateMultiples(next)
+	}
+
+	return list
+}
+
+// FieldsRecursive returns all the fields of the struct type T, including
+// the fields promoted from embedded structs at any depth, regardless of
+// whether they are shadowed or ambiguous. If T is a pointer, the pointer
+// base type is used; the result is empty if T is not a struct.
+//
+// The fields are listed in depth-first order: each embedded field is
+// immediately followed by the fields of the embedded struct (if any), so
+// that the index path of a promoted field (see LookupFieldOrMethod) can
+// be reconstructed from the preceding embedded fields. An embedded type
+// whose fields are already being listed, as is the case for a recursive
+// type such as "type T struct{ *T }", contributes its embedded field but
+// not its fields again.
+func FieldsRecursive(T Type) []*Var {
+	var list []*Var
+	var visit func(T Type, path map[*Named]bool)
+	visit = func(T Type, path map[*Named]bool) {
+		T, _ = deref(T)
+		if named, _ := T.(*Named); named != nil {
+			if path[named] {
+				return // cycle
+			}
+			path[named] = true
+			defer delete(path, named)
+		}
+		s, _ := T.Underlying().(*Struct)
+		if s == nil {
+			return
+		}
+		for _, f := range s.fields {
+			list = append(list, f)
+			if f.anonymous {
+				visit(f.typ, path)
+			}
+		}
+	}
+	visit(T, make(map[*Named]bool))
+	return list
+}
+
+// Lookup returns the object denoted by the qualified name, which is a
+// package path followed by the name of a package-level object, and
+// optionally by the name of a field or method of that object if it is
+// a type, all separated by dots; for instance "io.Reader", "bytes.Buffer",
+// "bytes.Buffer.WriteString", or "golang.org/x/tools/go/types.Info.Types".
+// The package is looked up in packages, indexed by package path; if more
+// than one prefix of the name is a package path (as for the paths
+// "gopkg.in/yaml" and "gopkg.in/yaml.v2"), the longest such prefix is
+// used. Fields and methods are looked up with LookupFieldOrMethod for an
+// addressable variable of the named type; thus methods with pointer
+// receivers are found as well. An error is returned if no such object
+// exists.
+func Lookup(packages map[string]*Package, qualified string) (Object, error) {
+	// find package: the package path ends at a dot after the last slash
+	var pkg *Package
+	var rest string
+	start := strings.LastIndex(qualified, "/") + 1
+	for i := start; i < len(qualified); i++ {
+		if qualified[i] == '.' {
+			if p := packages[qualified[:i]]; p != nil {
+				pkg = p
+				rest = qualified[i+1:]
+			}
+		}
+	}
+	if pkg == nil {
+		return nil, fmt.Errorf("%s: no package found", qualified)
+	}
+
+	name, member := rest, ""
+	if i := strings.Index(rest, "."); i >= 0 {
+		name, member = rest[:i], rest[i+1:]
+		if strings.Contains(member, ".") {
+			return nil, fmt.Errorf("%s: too many name components", qualified)
+		}
+	}
+
+	obj := pkg.scope.Lookup(name)
+	if obj == nil {
+		return nil, fmt.Errorf("%s: %s not declared in package %s", qualified, name, pkg.path)
+	}
+	if member == "" {
+		return obj, nil
+	}
+
+	tname, _ := obj.(*TypeName)
+	if tname == nil {
+		return nil, fmt.Errorf("%s: %s is not a type", qualified, name)
+	}
+	m, index, _ := LookupFieldOrMethod(tname.typ, true, pkg, member)
+	if m == nil {
+		if index != nil {
+			return nil, fmt.Errorf("%s: ambiguous selector %s", qualified, member)
+		}
+		return nil, fmt.Errorf("%s: %s has no field or method %s", qualified, name, member)
+	}
+	return m, nil
+}
+
+// ResolveMethod returns the method that is invoked by a call x.name()
+// for a (not addressable) value x of type T, as seen from package pkg.
+// The method is looked up as for the selector x.name by the type checker,
+// via LookupFieldOrMethod; thus it is the method denoted by the respective
+// MethodVal Selection, which may be promoted through several (pointer)
+// embedded fields. For calls on addressable variables of (non-pointer)
+// type T, which may invoke methods with pointer receivers, use NewPointer(T)
+// for T. If the method is declared by an interface (T is an interface or
+// the method is promoted from an embedded interface), the result is the
+// abstract interface method. An error is returned if x.name does not
+// denote a method.
+func ResolveMethod(T Type, name string, pkg *Package) (*Func, error) {
+	obj, index, indirect := LookupFieldOrMethod(T, false, pkg, name)
+	if obj == nil {
+		switch {
+		case index != nil:
+			return nil, fmt.Errorf("ambiguous selector %s", name)
+		case indirect:
+			return nil, fmt.Errorf("%s is not in method set of %s", name, T)
+		}
+		return nil, fmt.Errorf("%s has no field or method %s", T, name)
+	}
+	m, _ := obj.(*Func)
+	if m == nil {
+		return nil, fmt.Errorf("%s.%s is a field, not a method", T, name)
+	}
+	return m, nil
+}
+
 // MissingMethod returns (nil, false) if V implements T, otherwise it
 // returns a missing method required by T and whether it is missing or
 // just has the wrong type.
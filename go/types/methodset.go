@@ -59,6 +59,75 @@ func (s *MethodSet) Lookup(pkg *Package, name string) *Selection {
 	return nil
 }
 
+// Exported returns the method set consisting of the exported methods
+// in s. The selections are those of s; in particular, the index paths
+// of promoted methods are preserved. Methods promoted via unexported
+// embedded fields are included if they are exported themselves.
+func (s *MethodSet) Exported() *MethodSet {
+	var list []*Selection
+	for _, m := range s.list {
+		if m.obj.Exported() {
+			list = append(list, m)
+		}
+	}
+	if len(list) == 0 {
+		return &emptyMethodSet
+	}
+	return &MethodSet{list}
+}
+
+// ExportedMethodSet returns the exported methods of the method set of
+// type T; it is shorthand for NewMethodSet(T).Exported().
+func ExportedMethodSet(T Type) *MethodSet {
+	return NewMethodSet(T).Exported()
+}
+
+// Satisfies reports whether a type with the given method set implements
+// the interface iface, that is, whether for each method of iface, methods
+// contains a method with the same identity (the same name and, for
+// unexported methods, the same package) and an identical signature.
+// Since both methods and the method set of iface are ordered by method
+// identity, the comparison is linear in their sizes; this makes Satisfies
+// suitable for testing a precomputed method set (see NewMethodSet and
+// MethodSetCache) against many interfaces. The interface must be complete
+// (see Interface.Complete).
+func Satisfies(methods *MethodSet, iface *Interface) bool {
+	var list []*Selection
+	if methods != nil {
+		list = methods.list
+	}
+	i := 0
+	for _, m := range iface.allMethods {
+		id := m.Id()
+		for i < len(list) && list[i].obj.Id() < id {
+			i++
+		}
+		if i == len(list) || list[i].obj.Id() != id || !Identical(list[i].obj.Type(), m.typ) {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+// ImplementedInterfaces returns the interfaces in ifaces that are
+// implemented by type T, in the order in which they appear in ifaces.
+// The interfaces are tested against the method set of T; in particular,
+// methods with pointer receivers are only considered if T is a pointer
+// type: to find the interfaces implemented by a value of type *N for a
+// named type N, call ImplementedInterfaces(NewPointer(N), ifaces). The
+// interfaces must be complete (see Interface.Complete).
+func ImplementedInterfaces(T Type, ifaces []*Interface) []*Interface {
+	mset := NewMethodSet(T)
+	var res []*Interface
+	for _, iface := range ifaces {
+		if Satisfies(mset, iface) {
+			res = append(res, iface)
+		}
+	}
+	return res
+}
+
 // Shared empty method set.
 var emptyMethodSet MethodSet
 
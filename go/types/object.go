@@ -161,6 +161,7 @@ type Var struct {
 	visited   bool // for initialization cycle detection
 	isField   bool // var is struct field
 	used      bool // set if the variable was used
+	inferred  bool // set if the variable's type was inferred from its initialization expression
 }
 
 func NewVar(pos token.Pos, pkg *Package, name string, typ Type) *Var {
@@ -179,6 +180,14 @@ func (obj *Var) Anonymous() bool { return obj.anonymous }
 
 func (obj *Var) IsField() bool { return obj.isField }
 
+// TypeInferred reports whether the type of variable obj was inferred from
+// its initialization expression, as for the variables declared by var x = e
+// or x := e, or by a range clause using :=, rather than declared explicitly
+// as in var x T = e. In either case, obj.Type() is the variable's final type;
+// for inferred types, this is the default type of an untyped initialization
+// expression.
+func (obj *Var) TypeInferred() bool { return obj.inferred }
+
 // A Func represents a declared function, concrete method, or abstract
 // (interface) method.  Its Type() is always a *Signature.
 // An abstract method may belong to many interfaces due to embedding.
@@ -207,6 +216,40 @@ func (obj *Func) Scope() *Scope {
 	return obj.typ.(*Signature).scope
 }
 
+// IsInterfaceMethod reports whether obj is an (abstract) interface
+// method rather than a function or a concrete method. The receiver of
+// an interface method is always of interface type: its type is the
+// enclosing or embedded interface, either as a *Named or an *Interface
+// (see Signature.Recv).
+func (obj *Func) IsInterfaceMethod() bool {
+	if sig, _ := obj.typ.(*Signature); sig != nil && sig.recv != nil {
+		_, ok := sig.recv.typ.Underlying().(*Interface)
+		return ok
+	}
+	return false
+}
+
+// ReceiverNamed returns the base type of the receiver of method obj,
+// with a pointer indirection removed, and reports whether the receiver
+// is a pointer. The result is (nil, false) if obj is not a method, or if
+// the receiver base type is not a named type (e.g., for an interface
+// method declared in an interface literal).
+func (obj *Func) ReceiverNamed() (_ *Named, isPtr bool) {
+	sig, _ := obj.typ.(*Signature)
+	if sig == nil || sig.recv == nil {
+		return nil, false
+	}
+	typ := sig.recv.typ
+	if p, _ := typ.(*Pointer); p != nil {
+		typ = p.base
+		isPtr = true
+	}
+	if named, _ := typ.(*Named); named != nil {
+		return named, isPtr
+	}
+	return nil, false
+}
+
 // A Label represents a declared label.
 type Label struct {
 	object
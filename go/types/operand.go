@@ -8,6 +8,7 @@ package types
 
 import (
 	"bytes"
+	"fmt"
 	"go/ast"
 	"go/token"
 
@@ -203,7 +204,9 @@ func (x *operand) isNil() bool {
 //           overlapping in functionality. Need to simplify and clean up.
 
 // assignableTo reports whether x is assignable to a variable of type T.
-func (x *operand) assignableTo(conf *Config, T Type) bool {
+// If the result is false and a non-nil reason is provided, *reason is
+// set to a description of the incompatibility, if one can be given.
+func (x *operand) assignableTo(conf *Config, T Type, reason *string) bool {
 	if x.mode == invalid || T == Typ[Invalid] {
 		return true // avoid spurious errors
 	}
@@ -217,27 +220,59 @@ func (x *operand) assignableTo(conf *Config, T Type) bool {
 
 	Vu := V.Underlying()
 	Tu := T.Underlying()
+	if Vu == nil || Tu == nil {
+		return false // named type not set up yet (see NewNamed)
+	}
 
 	// T is an interface type and x implements T
 	// (Do this check first as it might succeed early.)
 	if Ti, ok := Tu.(*Interface); ok {
-		if Implements(x.typ, Ti) {
+		m, wrongType := MissingMethod(x.typ, Ti, true)
+		if m == nil {
 			return true
 		}
+		if reason != nil {
+			switch {
+			case wrongType:
+				*reason = fmt.Sprintf("wrong type for method %s", m.name)
+			case hasPtrRecvMethod(V, m):
+				*reason = fmt.Sprintf("method %s has pointer receiver", m.name)
+			default:
+				*reason = fmt.Sprintf("missing method %s", m.name)
+			}
+		}
 	}
 
 	// x's type V and T have identical underlying types
 	// and at least one of V or T is not a named type
-	if Identical(Vu, Tu) && (!isNamed(V) || !isNamed(T)) {
-		return true
+	if Identical(Vu, Tu) {
+		if !isNamed(V) || !isNamed(T) {
+			return true
+		}
+		if reason != nil {
+			*reason = fmt.Sprintf("%s and %s are different named types", V, T)
+		}
+		return false
 	}
 
 	// x is a bidirectional channel value, T is a channel
 	// type, x's type V and T have identical element types,
 	// and at least one of V or T is not a named type
-	if Vc, ok := Vu.(*Chan); ok && Vc.dir == SendRecv {
+	if Vc, ok := Vu.(*Chan); ok {
 		if Tc, ok := Tu.(*Chan); ok && Identical(Vc.elem, Tc.elem) {
-			return !isNamed(V) || !isNamed(T)
+			if Vc.dir != SendRecv {
+				if reason != nil {
+					*reason = "channel directions differ"
+				}
+				return false
+			}
+			if !isNamed(V) || !isNamed(T) {
+				return true
+			}
+			if reason != nil {
+				*reason = fmt.Sprintf("%s and %s are different named types", V, T)
+			}
+			return false
 		}
 	}
 
@@ -252,6 +287,9 @@ func (x *operand) assignableTo(conf *Config, T Type) bool {
 		case *Pointer, *Signature, *Slice, *Map, *Chan, *Interface:
 			return true
 		}
+		if reason != nil {
+			*reason = fmt.Sprintf("nil is not a valid value of type %s", T)
+		}
 		return false
 	}
 
@@ -262,7 +300,13 @@ func (x *operand) assignableTo(conf *Config, T Type) bool {
 		switch t := Tu.(type) {
 		case *Basic:
 			if x.mode == constant {
-				return representableConst(x.val, conf, t.kind, nil)
+				if representableConst(x.val, conf, t.kind, nil) {
+					return true
+				}
+				if reason != nil {
+					*reason = fmt.Sprintf("constant %s is not representable as %s", x.val, T)
+				}
+				return false
 			}
 			// The result of a comparison is an untyped boolean,
 			// but may not be a constant.
@@ -276,9 +320,22 @@ func (x *operand) assignableTo(conf *Config, T Type) bool {
 		}
 	}
 
+	if reason != nil && *reason == "" {
+		*reason = fmt.Sprintf("%s and %s have different underlying types", V, T)
+	}
 	return false
 }
 
+// hasPtrRecvMethod reports whether m, which is missing from the method
+// set of V, exists in the method set of *V.
+func hasPtrRecvMethod(V Type, m *Func) bool {
+	if _, isPtr := V.Underlying().(*Pointer); isPtr {
+		return false
+	}
+	obj, _, indirect := lookupFieldOrMethod(V, false, m.pkg, m.name)
+	return obj == nil && indirect
+}
+
 // isInteger reports whether x is a (typed or untyped) integer value.
 func (x *operand) isInteger() bool {
 	return x.mode == invalid ||
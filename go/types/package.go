@@ -4,7 +4,10 @@
 
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 // A Package describes a Go package.
 type Package struct {
@@ -47,12 +50,71 @@ func (pkg *Package) MarkComplete() { pkg.complete = true }
 
 // Imports returns the list of packages explicitly imported by
 // pkg; the list is in source order. Package unsafe is excluded.
+// For packages created by the type checker, each package appears
+// once, at the position of its first import in the files in the
+// order in which they were provided to the checker; thus the list
+// is deterministic.
 func (pkg *Package) Imports() []*Package { return pkg.imports }
 
 // SetImports sets the list of explicitly imported packages to list.
 // It is the caller's responsibility to make sure list elements are unique.
 func (pkg *Package) SetImports(list []*Package) { pkg.imports = list }
 
+// PackageTypes returns the named types declared at package level in pkg;
+// the respective type names are available via Named.Obj. The types are
+// in source order for packages created by the type checker, and sorted
+// by name otherwise (e.g., for imported packages, whose objects carry no
+// source order). Types declared in other packages are not included.
+func PackageTypes(pkg *Package) []*Named {
+	var objs []Object
+	for _, name := range pkg.scope.Names() {
+		if obj, _ := pkg.scope.Lookup(name).(*TypeName); obj != nil && obj.pkg == pkg {
+			if _, ok := obj.typ.(*Named); ok {
+				objs = append(objs, obj)
+			}
+		}
+	}
+	sort.Stable(inSourceOrder(objs))
+	list := make([]*Named, len(objs))
+	for i, obj := range objs {
+		list[i] = obj.Type().(*Named)
+	}
+	return list
+}
+
+// AllDependencies returns the transitive closure of the packages
+// imported by pkg (see Package.Imports), excluding pkg itself. The list
+// is in dependency order: each package appears after all the packages it
+// depends on; among the imports of a package, packages are visited in
+// order of their import paths. Thus the result is deterministic and does
+// not depend on the order of the imports. Import cycles are tolerated:
+// each package is listed only once.
+func AllDependencies(pkg *Package) []*Package {
+	var list []*Package
+	seen := map[*Package]bool{pkg: true}
+	var visit func(pkg *Package)
+	visit = func(pkg *Package) {
+		imports := append([]*Package(nil), pkg.imports...)
+		sort.Sort(byPath(imports))
+		for _, imp := range imports {
+			if !seen[imp] {
+				seen[imp] = true
+				visit(imp)
+				list = append(list, imp)
+			}
+		}
+	}
+	visit(pkg)
+	return list
+}
+
+// byPath sorts packages by their import paths.
+type byPath []*Package
+
+func (a byPath) Len() int           { return len(a) }
+func (a byPath) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byPath) Less(i, j int) bool { return a[i].path < a[j].path }
+
 func (pkg *Package) String() string {
 	return fmt.Sprintf("package %s (%q)", pkg.name, pkg.path)
 }
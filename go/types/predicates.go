@@ -78,22 +78,186 @@ func isInterface(typ Type) bool {
 
 // Comparable reports whether values of type T are comparable.
 func Comparable(T Type) bool {
+	ok, _ := comparable(T, nil)
+	return ok
+}
+
+// ComparableReason reports whether values of type T are comparable.
+// If they are not, because is the (possibly nested) component of T
+// that is not comparable: T itself if it is a slice, map, or function
+// type, or the first incomparable struct field or array element type,
+// recursively. If T is comparable, because is nil.
+func ComparableReason(T Type) (ok bool, because Type) {
+	return comparable(T, nil)
+}
+
+// comparable implements ComparableReason; seen tracks the named
+// struct and array types on the path to T to guard against invalid
+// recursive types.
+func comparable(T Type, seen map[*Named]bool) (bool, Type) {
+	if t, _ := T.(*Named); t != nil && isComposite(t.underlying) {
+		if seen[t] {
+			return true, nil // avoid follow-up errors for invalid cycles
+		}
+		if seen == nil {
+			seen = make(map[*Named]bool)
+		}
+		seen[t] = true
+		defer delete(seen, t)
+	}
+
 	switch t := T.Underlying().(type) {
 	case *Basic:
 		// assume invalid types to be comparable
 		// to avoid follow-up errors
-		return t.kind != UntypedNil
+		if t.kind != UntypedNil {
+			return true, nil
+		}
 	case *Pointer, *Interface, *Chan:
-		return true
+		return true, nil
 	case *Struct:
 		for _, f := range t.fields {
-			if !Comparable(f.typ) {
+			if ok, because := comparable(f.typ, seen); !ok {
+				return false, because
+			}
+		}
+		return true, nil
+	case *Array:
+		return comparable(t.elem, seen)
+	}
+	return false, T
+}
+
+// IsError reports whether t is the predeclared type error. It is false
+// for all other types, including named types whose underlying type is
+// the underlying type of error (see HasErrorUnderlying) and interfaces
+// with a single method other than Error() string.
+func IsError(t Type) bool {
+	return t == universeError
+}
+
+// HasErrorUnderlying reports whether the underlying type of t is the
+// interface interface{ Error() string } underlying the predeclared type
+// error. This is the case for error itself, for types declared as
+// "type E error", and for that interface literal.
+func HasErrorUnderlying(t Type) bool {
+	return Identical(t.Underlying(), universeError.underlying)
+}
+
+// ValidSize reports whether values of type T have a finite size, that is,
+// whether T does not contain itself directly, via struct fields or array
+// elements. Recursion through pointer, slice, map, channel, function, or
+// interface types is legal since these types are of fixed size regardless
+// of their element types. The type-checker reports an "illegal cycle"
+// error for declarations of such types; ValidSize is useful for types
+// that are constructed programmatically.
+func ValidSize(T Type) bool {
+	return validSize(T, nil, make(map[*Named]bool))
+}
+
+// validSize implements ValidSize; path is the list of named types
+// whose underlying types contain T, and valid records the named types
+// already known to be of finite size.
+func validSize(T Type, path []*Named, valid map[*Named]bool) bool {
+	switch t := T.(type) {
+	case *Named:
+		if valid[t] {
+			return true
+		}
+		for _, n := range path {
+			if n == t {
+				return false // t contains itself
+			}
+		}
+		if !validSize(t.underlying, append(path, t), valid) {
+			return false
+		}
+		valid[t] = true
+	case *Array:
+		return validSize(t.elem, path, valid)
+	case *Struct:
+		for _, f := range t.fields {
+			if !validSize(f.typ, path, valid) {
 				return false
 			}
 		}
-		return true
+	}
+	return true
+}
+
+// MutuallyRecursive reports whether the definitions of the named types
+// a and b refer to each other, directly or via other named types; that
+// is, whether a and b belong to the same group of recursive types. In
+// contrast to ValidSize, references through any type constructor count,
+// including pointer, slice, map, channel, function, and interface types.
+// Method declarations are not part of a type's definition and are thus
+// ignored (but the methods of an interface type are not). For a == b,
+// the result reports whether a refers to itself.
+func MutuallyRecursive(a, b *Named) bool {
+	return refersTo(a.underlying, b, make(map[*Named]bool)) &&
+		refersTo(b.underlying, a, make(map[*Named]bool))
+}
+
+// refersTo reports whether type T refers to the named type target.
+// seen records the named types whose underlying types were already
+// visited.
+func refersTo(T Type, target *Named, seen map[*Named]bool) bool {
+	switch t := T.(type) {
+	case *Named:
+		if t == target {
+			return true
+		}
+		if seen[t] {
+			return false
+		}
+		seen[t] = true
+		return refersTo(t.underlying, target, seen)
 	case *Array:
-		return Comparable(t.elem)
+		return refersTo(t.elem, target, seen)
+	case *Slice:
+		return refersTo(t.elem, target, seen)
+	case *Struct:
+		for _, f := range t.fields {
+			if refersTo(f.typ, target, seen) {
+				return true
+			}
+		}
+	case *Pointer:
+		return refersTo(t.base, target, seen)
+	case *Tuple:
+		if t != nil {
+			for _, v := range t.vars {
+				if refersTo(v.typ, target, seen) {
+					return true
+				}
+			}
+		}
+	case *Signature:
+		return refersTo(t.params, target, seen) || refersTo(t.results, target, seen)
+	case *Interface:
+		for _, e := range t.embeddeds {
+			if refersTo(e, target, seen) {
+				return true
+			}
+		}
+		for _, m := range t.allMethods {
+			if refersTo(m.typ, target, seen) {
+				return true
+			}
+		}
+	case *Map:
+		return refersTo(t.key, target, seen) || refersTo(t.elem, target, seen)
+	case *Chan:
+		return refersTo(t.elem, target, seen)
+	}
+	return false
+}
+
+// isComposite reports whether t is a struct or array type.
+func isComposite(t Type) bool {
+	switch t.(type) {
+	case *Struct, *Array:
+		return true
 	}
 	return false
 }
@@ -276,6 +440,10 @@ func identical(x, y Type, p *ifacePair) bool {
 			return x.obj == y.obj
 		}
 
+	case nil:
+		// underlying type of a named type that is not set up yet
+		// (see NewNamed); only identical to itself (handled above)
+
 	default:
 		unreachable()
 	}
@@ -283,6 +451,16 @@ func identical(x, y Type, p *ifacePair) bool {
 	return false
 }
 
+// Default returns the default type of an untyped constant of type t:
+// bool, int, rune, float64, complex128, or string for untyped boolean,
+// integer, rune, floating-point, complex, and string constants. The default
+// type of an untyped rune constant is the predeclared type rune, which is
+// an alias for int32; it is returned as UniverseRune so that it prints as
+// "rune". For all other types, including untyped nil, the result is t.
+func Default(t Type) Type {
+	return defaultType(t)
+}
+
 // defaultType returns the default "typed" type for an "untyped" type;
 // it returns the incoming type for all other types. The default type
 // for untyped nil is untyped nil.
@@ -306,3 +484,86 @@ func defaultType(typ Type) Type {
 	}
 	return typ
 }
+
+// CommonType returns the type that operands of the given types assume
+// when they are combined, as in a binary operation, following the rules
+// for untyped operands: if all types are untyped numeric types, the
+// result is the "largest" of them, in the order integer, rune,
+// floating-point, complex (e.g., untyped float for untyped int and
+// untyped float); otherwise the untyped types must all be the same, or
+// there must be (only) identical typed types that all untyped types can
+// be converted to, and the result is that typed type. The untyped nil
+// converts to pointer, function, slice, map, channel, and interface
+// types and unsafe.Pointer; other untyped types convert to basic types
+// of the same category (boolean, numeric, or string) and to empty
+// interfaces. Whether a particular constant value is representable by
+// the result type is not considered. If there is no common type, or if
+// types is empty, the result is (nil, false).
+func CommonType(types []Type) (Type, bool) {
+	var res Type
+	for _, t := range types {
+		if res == nil {
+			res = t
+			continue
+		}
+		if res = commonType(res, t); res == nil {
+			return nil, false
+		}
+	}
+	return res, res != nil
+}
+
+// commonType returns the common type of x and y, or nil.
+func commonType(x, y Type) Type {
+	switch {
+	case isTyped(x) && isTyped(y):
+		if Identical(x, y) {
+			return x
+		}
+	case isTyped(x):
+		if untypedConvertibleTo(y.(*Basic), x) {
+			return x
+		}
+	case isTyped(y):
+		if untypedConvertibleTo(x.(*Basic), y) {
+			return y
+		}
+	default:
+		// both x and y are untyped
+		xkind := x.(*Basic).kind
+		ykind := y.(*Basic).kind
+		if isNumeric(x) && isNumeric(y) {
+			if xkind < ykind {
+				return y
+			}
+			return x
+		}
+		if xkind == ykind {
+			return x
+		}
+	}
+	return nil
+}
+
+// untypedConvertibleTo reports whether an operand of untyped type x
+// may be implicitly converted to the typed type target, irrespective
+// of its value.
+func untypedConvertibleTo(x *Basic, target Type) bool {
+	if x.kind == UntypedNil {
+		return hasNil(target)
+	}
+	switch t := target.Underlying().(type) {
+	case *Basic:
+		switch x.kind {
+		case UntypedBool:
+			return isBoolean(t)
+		case UntypedInt, UntypedRune, UntypedFloat, UntypedComplex:
+			return isNumeric(t)
+		case UntypedString:
+			return isString(t)
+		}
+	case *Interface:
+		return t.Empty()
+	}
+	return false
+}
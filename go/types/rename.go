@@ -0,0 +1,419 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements CheckRename.
+
+package types
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"unicode"
+)
+
+// CheckRename reports the conflicts that renaming the object obj declared
+// in package pkg to newName would introduce. The type information for pkg
+// must be provided by info, with the Defs, Uses, and Scopes maps populated;
+// the Types map is used to find the struct types declaring fields, if
+// present. pkg and info must describe the same, error-free package.
+//
+// The following conflicts are reported:
+//
+//	- newName is not a valid identifier, or obj is not declared in pkg;
+//	- another object named newName is declared in the same scope as obj
+//	  (or, for package-level objects, the package and a file scope), or
+//	  in the same struct, method set, or interface as a field or method;
+//	- a reference to obj would be shadowed by (and thus refer to) another
+//	  object named newName declared in a scope between the reference and
+//	  obj's declaration;
+//	- the renamed obj would shadow another object named newName for a
+//	  reference within obj's scope;
+//	- a package-level object, field, or method would change from being
+//	  exported to being unexported, or vice versa;
+//	- a type declared in pkg would no longer implement an interface
+//	  declared in pkg or in one of its imports, because an interface
+//	  method or a method implementing it is renamed.
+//
+// The result is nil if there are no conflicts. Uses of obj in other
+// packages are not considered. Local objects are assumed to be in scope
+// from their declaring identifier on; thus a conflict may be reported for
+// a reference in the initialization expression of a declaration of a
+// variable named newName, even though that variable is not in scope yet.
+func CheckRename(pkg *Package, info *Info, obj Object, newName string) []error {
+	oldName := obj.Name()
+	if newName == oldName {
+		return nil
+	}
+
+	var errs []error
+	seen := make(map[string]bool) // multiple references may cause the same conflict
+	conflict := func(format string, args ...interface{}) {
+		if msg := fmt.Sprintf(format, args...); !seen[msg] {
+			seen[msg] = true
+			errs = append(errs, fmt.Errorf("renaming %s to %s: %s", oldName, newName, msg))
+		}
+	}
+
+	if !isIdentifier(newName) {
+		conflict("%q is not a valid identifier", newName)
+		return errs
+	}
+	if obj.Pkg() != pkg {
+		conflict("%s is not declared in package %s", ObjectString(pkg, obj), pkg.path)
+		return errs
+	}
+
+	r := renamer{pkg: pkg, info: info, obj: obj, newName: newName}
+
+	if newName == "_" {
+		for _, use := range info.Uses {
+			if use == obj {
+				conflict("%s is used", ObjectString(pkg, obj))
+				break
+			}
+		}
+	}
+
+	// visibility
+	isMember := false
+	switch obj := obj.(type) {
+	case *Var:
+		isMember = obj.isField
+	case *Func:
+		isMember = obj.typ.(*Signature).recv != nil
+	}
+	if obj.Parent() == pkg.scope || isMember {
+		if oldExp, newExp := ast.IsExported(oldName), ast.IsExported(newName); oldExp && !newExp {
+			conflict("%s would become unexported", ObjectString(pkg, obj))
+		} else if !oldExp && newExp {
+			conflict("%s would become exported", ObjectString(pkg, obj))
+		}
+	}
+
+	if isMember {
+		r.checkMember(conflict)
+	} else if obj.Parent() != nil {
+		r.checkLexical(conflict)
+	}
+
+	return errs
+}
+
+// A renamer holds the state shared by the checks of CheckRename.
+type renamer struct {
+	pkg     *Package
+	info    *Info
+	obj     Object
+	newName string
+	extents []scopeExtent // scope extents for innermost; computed lazily
+}
+
+// checkLexical checks the renaming of an object declared in a
+// (lexical) scope.
+func (r *renamer) checkLexical(conflict func(string, ...interface{})) {
+	pkg, obj, newName := r.pkg, r.obj, r.newName
+	scope := obj.Parent()
+
+	// conflicting declarations in the same scope
+	if alt := scope.Lookup(newName); alt != nil {
+		conflict("%s is declared in the same scope", ObjectString(pkg, alt))
+	}
+	if _, ok := obj.(*Label); ok {
+		return // labels don't nest
+	}
+	switch {
+	case scope == pkg.scope:
+		// package-level objects conflict with imports in file scopes
+		for _, s := range scope.children {
+			if alt := s.Lookup(newName); alt != nil {
+				conflict("%s is declared in a file scope", ObjectString(pkg, alt))
+			}
+		}
+	case scope.parent == pkg.scope:
+		// file-level objects (imports) conflict with package-level objects
+		if alt := pkg.scope.Lookup(newName); alt != nil {
+			conflict("%s is declared in the package scope", ObjectString(pkg, alt))
+		}
+	}
+
+	oldName := obj.Name()
+	for id, use := range r.info.Uses {
+		if id.Name != oldName && id.Name != newName {
+			continue // not affected by the renaming
+		}
+		switch {
+		case use == obj:
+			// A reference to obj must not be captured by a declaration
+			// of newName between the reference and obj's declaration.
+			for s := r.innermost(id.Pos()); s != nil && s != scope; s = s.parent {
+				if alt := s.Lookup(newName); alt != nil && r.visible(alt, s, id.Pos()) {
+					conflict("a reference to %s would refer to %s", ObjectString(pkg, obj), ObjectString(pkg, alt))
+					break
+				}
+			}
+
+		case id.Name == newName && use != nil && encloses(use.Parent(), scope) && use.Parent() != scope:
+			// A reference within obj's scope to another object named
+			// newName declared further out must not be captured by obj.
+			if encloses(scope, r.innermost(id.Pos())) && r.visible(obj, scope, id.Pos()) {
+				conflict("%s would shadow %s", ObjectString(pkg, obj), ObjectString(pkg, use))
+			}
+		}
+	}
+}
+
+// checkMember checks the renaming of a field or method.
+func (r *renamer) checkMember(conflict func(string, ...interface{})) {
+	pkg, newName := r.pkg, r.newName
+
+	switch obj := r.obj.(type) {
+	case *Var:
+		if obj.anonymous {
+			conflict("%s is an embedded field; rename its type instead", ObjectString(pkg, obj))
+			return
+		}
+		for _, tv := range r.info.Types {
+			s, _ := tv.Type.(*Struct)
+			if !tv.IsType() || s == nil || !hasField(s, obj) {
+				continue
+			}
+			for _, f := range s.fields {
+				if f.name == newName {
+					conflict("%s is declared in the same struct", ObjectString(pkg, f))
+				}
+			}
+			for _, named := range PackageTypes(pkg) {
+				if named.underlying == s {
+					for _, m := range named.methods {
+						if m.name == newName {
+							conflict("%s is declared for %s", ObjectString(pkg, m), named)
+						}
+					}
+				}
+			}
+			break
+		}
+
+	case *Func:
+		recv := obj.typ.(*Signature).recv.typ
+		if iface, _ := recv.Underlying().(*Interface); iface != nil {
+			// interface method
+			for _, m := range iface.allMethods {
+				if m.name == newName {
+					conflict("%s is declared in the same interface", ObjectString(pkg, m))
+				}
+			}
+			for _, named := range PackageTypes(pkg) {
+				if _, ok := named.underlying.(*Interface); ok {
+					continue
+				}
+				if impl := implementer(named, iface); impl != nil && !hasMethod(impl, pkg, newName, obj.typ) {
+					conflict("%s would no longer implement %s", impl, recv)
+				}
+			}
+			return
+		}
+
+		// concrete method
+		named, _ := obj.ReceiverNamed()
+		if named == nil {
+			return
+		}
+		if alt, index, _ := LookupFieldOrMethod(named, true, pkg, newName); alt != nil {
+			if len(index) == 1 {
+				conflict("%s is declared for %s", ObjectString(pkg, alt), named)
+			} else {
+				conflict("%s would shadow promoted %s", ObjectString(pkg, obj), ObjectString(pkg, alt))
+			}
+		}
+		for _, iface := range r.interfaces() {
+			if !hasMethod(iface.obj.typ, pkg, obj.name, obj.typ) {
+				continue
+			}
+			if implementer(named, iface.typ) != nil {
+				conflict("%s would no longer implement %s", named, TypeString(pkg, iface.obj.typ))
+			}
+		}
+	}
+}
+
+// implementer returns named or a pointer to named, whichever implements
+// iface, or nil if neither does.
+func implementer(named *Named, iface *Interface) Type {
+	if len(iface.allMethods) == 0 {
+		return nil // empty interfaces are not affected by method renames
+	}
+	if Implements(named, iface) {
+		return named
+	}
+	if ptr := NewPointer(named); Implements(ptr, iface) {
+		return ptr
+	}
+	return nil
+}
+
+// A namedInterface is an interface together with its type name.
+type namedInterface struct {
+	obj *TypeName
+	typ *Interface
+}
+
+// interfaces returns the named interfaces declared in r.pkg and
+// the exported named interfaces declared in the packages it imports.
+func (r *renamer) interfaces() []namedInterface {
+	var list []namedInterface
+	collect := func(pkg *Package, all bool) {
+		for _, named := range PackageTypes(pkg) {
+			if iface, _ := named.underlying.(*Interface); iface != nil && (all || named.obj.Exported()) {
+				list = append(list, namedInterface{named.obj, iface})
+			}
+		}
+	}
+	collect(r.pkg, true)
+	for _, imp := range r.pkg.imports {
+		collect(imp, false)
+	}
+	return list
+}
+
+// innermost returns the innermost scope of r.pkg containing pos;
+// the result is the package scope if no such scope was recorded.
+func (r *renamer) innermost(pos token.Pos) *Scope {
+	if r.extents == nil {
+		r.extents = scopeExtents(r.info)
+	}
+	list := r.extents
+	// find the last extent starting at or before pos;
+	// the innermost extent containing pos encloses it
+	i := sort.Search(len(list), func(i int) bool { return list[i].pos > pos }) - 1
+	for i >= 0 && pos >= list[i].end {
+		i = list[i].outer
+	}
+	if i < 0 {
+		return r.pkg.scope
+	}
+	return list[i].scope
+}
+
+// A scopeExtent describes the source range [pos, end) of a scope.
+// outer is the index of the innermost enclosing extent, or -1.
+type scopeExtent struct {
+	pos, end token.Pos
+	scope    *Scope
+	outer    int
+}
+
+// scopeExtents returns the extents of the local and file scopes recorded
+// in info, ordered by position such that enclosing extents come first.
+func scopeExtents(info *Info) []scopeExtent {
+	list := []scopeExtent{} // non-nil
+	add := func(pos, end token.Pos, s *Scope) {
+		if s != nil && pos < end {
+			list = append(list, scopeExtent{pos, end, s, -1})
+		}
+	}
+	for node := range info.Scopes {
+		file, _ := node.(*ast.File)
+		if file == nil {
+			continue
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			// Function scopes are recorded for the function type
+			// but extend over the entire function (excluding the
+			// name of a function declaration).
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				s := info.Scopes[n.Type]
+				add(n.Pos(), n.Name.Pos(), s)
+				add(n.Name.End(), n.End(), s)
+			case *ast.FuncLit:
+				add(n.Pos(), n.End(), info.Scopes[n.Type])
+			case *ast.FuncType, nil:
+				// nothing to do
+			default:
+				add(n.Pos(), n.End(), info.Scopes[n])
+			}
+			return true
+		})
+	}
+	sort.Sort(byExtent(list))
+
+	// determine the enclosing extents
+	var stack []int
+	for i := range list {
+		for len(stack) > 0 && list[stack[len(stack)-1]].end <= list[i].pos {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			list[i].outer = stack[len(stack)-1]
+		}
+		stack = append(stack, i)
+	}
+	return list
+}
+
+// byExtent sorts scope extents by start position; extents
+// starting at the same position are sorted outermost first.
+type byExtent []scopeExtent
+
+func (a byExtent) Len() int      { return len(a) }
+func (a byExtent) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byExtent) Less(i, j int) bool {
+	if a[i].pos != a[j].pos {
+		return a[i].pos < a[j].pos
+	}
+	return a[i].end > a[j].end
+}
+
+// visible reports whether obj, declared in scope s, is visible at pos.
+// Objects declared at package or file level are visible throughout;
+// local objects are visible after their declaration.
+func (r *renamer) visible(obj Object, s *Scope, pos token.Pos) bool {
+	return s == r.pkg.scope || s.parent == r.pkg.scope || obj.Pos() < pos
+}
+
+// encloses reports whether scope outer is s or one of its parents.
+func encloses(outer, s *Scope) bool {
+	for ; s != nil; s = s.parent {
+		if s == outer {
+			return true
+		}
+	}
+	return false
+}
+
+// hasField reports whether f is a field of s.
+func hasField(s *Struct, f *Var) bool {
+	for _, g := range s.fields {
+		if g == f {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMethod reports whether T has a method with the given name (as
+// seen from pkg) and a signature identical to the signature typ.
+func hasMethod(T Type, pkg *Package, name string, typ Type) bool {
+	m, _, _ := LookupFieldOrMethod(T, true, pkg, name)
+	if m, _ := m.(*Func); m != nil {
+		return Identical(m.typ, typ)
+	}
+	return false
+}
+
+// isIdentifier reports whether name is a valid (non-keyword) Go identifier.
+func isIdentifier(name string) bool {
+	if name == "" || token.Lookup(name).IsKeyword() {
+		return false
+	}
+	for i, ch := range name {
+		if !unicode.IsLetter(ch) && ch != '_' && (i == 0 || !unicode.IsDigit(ch)) {
+			return false
+		}
+	}
+	return true
+}
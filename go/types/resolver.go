@@ -122,23 +122,46 @@ func (check *Checker) filename(fileNo int) string {
 	return fmt.Sprintf("file[%d]", fileNo)
 }
 
+// removeRedeclared removes the objects from the package scope that are
+// redeclared at package level by the current package files. It is used
+// if Config.ReplaceDeclarations is set.
+func (check *Checker) removeRedeclared() {
+	scope := check.pkg.scope
+	remove := func(ident *ast.Ident) {
+		if name := ident.Name; name != "_" && name != "init" {
+			delete(scope.elems, name)
+		}
+	}
+	for _, file := range check.files {
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.ValueSpec:
+						for _, name := range s.Names {
+							remove(name)
+						}
+					case *ast.TypeSpec:
+						remove(s.Name)
+					}
+				}
+			case *ast.FuncDecl:
+				if d.Recv == nil {
+					remove(d.Name)
+				}
+			}
+		}
+	}
+}
+
 // collectObjects collects all file and package objects and inserts them
 // into their respective scopes. It also performs imports and associates
 // methods with receiver base type names.
 func (check *Checker) collectObjects() {
 	pkg := check.pkg
 
-	importer := check.conf.Import
-	if importer == nil {
-		if DefaultImport != nil {
-			importer = DefaultImport
-		} else {
-			// Panic if we encounter an import.
-			importer = func(map[string]*Package, string) (*Package, error) {
-				panic(`no Config.Import or DefaultImport (missing import _ "golang.org/x/tools/go/gcimporter"?)`)
-			}
-		}
-	}
+	importer := check.conf.importer()
 
 	// pkgImports is the set of packages already imported by any package file seen
 	// so far. Used to avoid duplicate entries in pkg.imports. Allocate and populate
@@ -168,6 +191,7 @@ func (check *Checker) collectObjects() {
 					case *ast.ImportSpec:
 						// import package
 						var imp *Package
+						placeholder := false
 						path, err := validatedImportPath(s.Path.Value)
 						if err != nil {
 							check.errorf(s.Path.Pos(), "invalid import path (%s)", err)
@@ -185,14 +209,21 @@ func (check *Checker) collectObjects() {
 							}
 							if err != nil {
 								check.errorf(s.Path.Pos(), "could not import %s (%s)", path, err)
-								continue
+								if imp == nil {
+									continue
+								}
+								// declare a placeholder in place of imp (see Importer);
+								// imp itself may be shared and must not be modified
+								imp = NewPackage(path, imp.name)
+								imp.fake = true
+								placeholder = true
 							}
 						}
 
 						// add package to list of explicit imports
 						// (this functionality is provided as a convenience
 						// for clients; it is not needed for type-checking)
-						if !pkgImports[imp] {
+						if !pkgImports[imp] && !placeholder {
 							pkgImports[imp] = true
 							if imp != Unsafe {
 								pkg.imports = append(pkg.imports, imp)
@@ -210,6 +241,7 @@ func (check *Checker) collectObjects() {
 						}
 
 						obj := NewPkgName(s.Pos(), pkg, name, imp)
+						check.fileImports = append(check.fileImports, fileImport{file, fileScope, obj})
 						if s.Name != nil {
 							// in a dot-import, the dot represents the package
 							check.recordDef(s.Name, obj)
@@ -220,6 +252,8 @@ func (check *Checker) collectObjects() {
 						// add import to file scope
 						if name == "." {
 							// merge imported scope with file scope
+							pkgName := obj
+							imp.scope.resolveAll()
 							for _, obj := range imp.scope.elems {
 								// A package scope may contain non-exported objects,
 								// do not import them!
@@ -234,6 +268,10 @@ func (check *Checker) collectObjects() {
 									// another package!)
 									check.declare(fileScope, nil, obj)
 									check.recordImplicit(s, obj)
+									if check.dotImportMap == nil {
+										check.dotImportMap = make(map[dotImportKey]*PkgName)
+									}
+									check.dotImportMap[dotImportKey{fileScope, obj}] = pkgName
 								}
 							}
 							// add position to set of dot-import positions for this file
@@ -390,6 +428,9 @@ func (check *Checker) packageObjects(objList []Object) {
 	typePath := make([]*TypeName, 0, 8)
 
 	for _, obj := range objList {
+		if check.conf.ExportedOnly && !check.exportedRoot(obj) {
+			continue // type-checked on demand if reachable
+		}
 		check.objDecl(obj, nil, typePath)
 	}
 
@@ -400,6 +441,19 @@ func (check *Checker) packageObjects(objList []Object) {
 	check.methods = nil
 }
 
+// exportedRoot reports whether obj is a package-level object that is
+// type-checked in ExportedOnly mode even if no other object refers to it.
+// Methods are checked together with their receiver base type.
+func (check *Checker) exportedRoot(obj Object) bool {
+	if !obj.Exported() {
+		return false
+	}
+	if d := check.objMap[obj]; d != nil && d.fdecl != nil && d.fdecl.Recv != nil {
+		return false
+	}
+	return true
+}
+
 // functionBodies typechecks all function bodies.
 func (check *Checker) functionBodies() {
 	for _, f := range check.funcs {
@@ -410,7 +464,7 @@ func (check *Checker) functionBodies() {
 // unusedImports checks for unused imports.
 func (check *Checker) unusedImports() {
 	// if function bodies are not checked, packages' uses are likely missing - don't check
-	if check.conf.IgnoreFuncBodies {
+	if check.conf.IgnoreFuncBodies || check.conf.ExportedOnly {
 		return
 	}
 
@@ -9,6 +9,7 @@ package types
 import (
 	"bytes"
 	"fmt"
+	"go/token"
 	"io"
 	"sort"
 	"strings"
@@ -24,8 +25,11 @@ import (
 type Scope struct {
 	parent   *Scope
 	children []*Scope
-	comment  string            // for debugging only
-	elems    map[string]Object // lazily allocated
+	comment  string                   // for debugging only
+	pos      token.Pos                // start of the scope's source extent, if known; for ordering children
+	elems    map[string]Object        // lazily allocated
+	resolve  func(name string) Object // if set, resolves objects on demand
+	pending  []string                 // names not yet resolved via resolve
 }
 
 // NewScope returns a new, empty scope contained in the given parent
@@ -43,10 +47,14 @@ func NewScope(parent *Scope, comment string) *Scope {
 func (s *Scope) Parent() *Scope { return s.parent }
 
 // Len() returns the number of scope elements.
-func (s *Scope) Len() int { return len(s.elems) }
+func (s *Scope) Len() int {
+	s.resolveAll()
+	return len(s.elems)
+}
 
 // Names returns the scope's element names in sorted order.
 func (s *Scope) Names() []string {
+	s.resolveAll()
 	names := make([]string, len(s.elems))
 	i := 0
 	for name := range s.elems {
@@ -61,12 +69,71 @@ func (s *Scope) Names() []string {
 func (s *Scope) NumChildren() int { return len(s.children) }
 
 // Child returns the i'th child scope for 0 <= i < NumChildren().
+// For scopes created by the type checker, the children of a package
+// scope (the file scopes) appear in the order in which the files were
+// provided to the checker, and the children of all other scopes appear
+// in source order once type-checking has completed.
 func (s *Scope) Child(i int) *Scope { return s.children[i] }
 
+// sortChildren sorts the children of s and of all its nested scopes
+// in source order. The type checker creates scopes in the order it
+// encounters them, which may differ from the source order (e.g., the
+// scope of a function may be created when the function is referenced).
+func (s *Scope) sortChildren() {
+	sort.Stable(scopesByPos(s.children))
+	for _, s := range s.children {
+		s.sortChildren()
+	}
+}
+
+type scopesByPos []*Scope
+
+func (a scopesByPos) Len() int           { return len(a) }
+func (a scopesByPos) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a scopesByPos) Less(i, j int) bool { return a[i].pos < a[j].pos }
+
 // Lookup returns the object in scope s with the given name if such an
 // object exists; otherwise the result is nil.
 func (s *Scope) Lookup(name string) Object {
-	return s.elems[name]
+	obj := s.elems[name]
+	if obj == nil && s.resolve != nil {
+		if obj = s.resolve(name); obj != nil {
+			if obj.Name() != name {
+				panic(fmt.Sprintf("scope resolver returned %s for name %s", obj, name))
+			}
+			s.Insert(obj)
+		}
+	}
+	return obj
+}
+
+// SetResolver sets the function used to resolve names on demand:
+// if s contains no object with a given name, Lookup calls resolve
+// with that name and, if the result is not nil, inserts it into s;
+// subsequent lookups of the name return the same object. A nil
+// result means that s contains no object with that name.
+//
+// The names list the objects that resolve is expected to provide,
+// in no particular order. Operations that need all scope elements
+// (Len, Names, WriteTo, and dot-imports of a package with scope s)
+// first resolve each of the names that wasn't looked up yet; names
+// may be nil if s is known to be used for lookups only.
+//
+// Resolvers permit an importer to provide the members of an imported
+// package incrementally, only for the members actually used by the
+// package being type-checked.
+func (s *Scope) SetResolver(resolve func(name string) Object, names []string) {
+	s.resolve = resolve
+	s.pending = names
+}
+
+// resolveAll resolves all pending names of s.
+func (s *Scope) resolveAll() {
+	names := s.pending
+	s.pending = nil
+	for _, name := range names {
+		s.Lookup(name)
+	}
 }
 
 // LookupParent follows the parent chain of scopes starting with s until
@@ -79,7 +146,7 @@ func (s *Scope) Lookup(name string) Object {
 // whose scope is the scope of the package that exported them.
 func (s *Scope) LookupParent(name string) (*Scope, Object) {
 	for ; s != nil; s = s.parent {
-		if obj := s.elems[name]; obj != nil {
+		if obj := s.Lookup(name); obj != nil {
 			return s, obj
 		}
 	}
@@ -115,6 +182,7 @@ func (s *Scope) WriteTo(w io.Writer, n int, recurse bool) {
 	const ind = ".  "
 	indn := strings.Repeat(ind, n)
 
+	s.resolveAll()
 	fmt.Fprintf(w, "%s%s scope %p {", indn, s.comment, s)
 	if len(s.elems) == 0 {
 		fmt.Fprintf(w, "}\n")
@@ -89,6 +89,26 @@ func (s *Selection) Type() Type {
 	return s.obj.Type()
 }
 
+// BoundSignature returns the signature of the function value denoted
+// by x.f as it appears at the use site, or nil for a field selection.
+// For a method value, the receiver x is bound and the result has no
+// receiver: for the declarations in the Selection example above, the
+// bound signature of p.m is func(). For a method expression, the receiver
+// becomes the first parameter, with the (value or pointer) receiver type
+// as written at the use site: the bound signature of T.m is func(T),
+// and that of (*T).m is func(*T).
+func (s *Selection) BoundSignature() *Signature {
+	switch s.kind {
+	case MethodVal:
+		sig := *s.obj.(*Func).typ.(*Signature)
+		sig.recv = nil
+		return &sig
+	case MethodExpr:
+		return s.Type().(*Signature)
+	}
+	return nil
+}
+
 // Index describes the path from x to f in x.f.
 // The last index entry is the field or method index of the type declaring f;
 // either:
@@ -105,6 +125,55 @@ func (s *Selection) Index() []int { return s.index }
 // x to f in x.f.
 func (s *Selection) Indirect() bool { return s.indirect }
 
+// Indirections describes the pointer indirections on the path from x
+// to f in x.f: for each entry of Index, the corresponding entry reports
+// whether the value reached so far (x for the first entry, the embedded
+// field selected by the previous entry otherwise) is a pointer that is
+// dereferenced before the entry is applied. Indirect reports whether any
+// of the entries is set.
+//
+// For example, for the declarations in the Selection example above,
+// the selection p.m has indirections {true, false}.
+func (s *Selection) Indirections() []bool {
+	list := make([]bool, len(s.index))
+	typ := s.recv
+	for i, index := range s.index {
+		if p, _ := typ.Underlying().(*Pointer); p != nil {
+			list[i] = true
+			typ = p.base
+		}
+		if i+1 < len(s.index) {
+			typ = typ.Underlying().(*Struct).fields[index].typ
+		}
+	}
+	return list
+}
+
+// PromotedFrom returns the named type whose field or method f is
+// promoted to x.f, i.e. the embedded type at the end of the path
+// described by Index. For a selection of a field or method declared
+// directly by (the type of) x, that is, when len(Index()) == 1, the
+// result is nil. For the declarations
+//
+//	type A struct{ B }
+//	type B struct{ b int }
+//
+// and a value a of type A, the selection a.b is promoted from B.
+func (s *Selection) PromotedFrom() *Named {
+	n := len(s.index)
+	if n <= 1 {
+		return nil
+	}
+	typ := s.recv
+	for _, index := range s.index[:n-1] {
+		typ, _ = deref(typ.Underlying())
+		typ = typ.Underlying().(*Struct).fields[index].typ
+	}
+	typ, _ = deref(typ)
+	named, _ := typ.(*Named)
+	return named
+}
+
 func (s *Selection) String() string { return SelectionString(nil, s) }
 
 // SelectionString returns the string form of s.
@@ -10,17 +10,19 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"sort"
 
 	"golang.org/x/tools/go/exact"
 )
 
 func (check *Checker) funcBody(decl *declInfo, name string, sig *Signature, body *ast.BlockStmt) {
-	if trace {
+	if check.tracing() {
 		if name == "" {
 			name = "<function literal>"
 		}
-		fmt.Printf("--- %s: %s {\n", name, sig)
-		defer fmt.Println("--- <end>")
+		w := check.traceWriter()
+		fmt.Fprintf(w, "--- %s: %s {\n", name, sig)
+		defer fmt.Fprintln(w, "--- <end>")
 	}
 
 	// save/restore current context and setup function context
@@ -54,16 +56,30 @@ func (check *Checker) funcBody(decl *declInfo, name string, sig *Signature, body
 }
 
 func (check *Checker) usage(scope *Scope) {
+	var unused []*Var
 	for _, obj := range scope.elems {
 		if v, _ := obj.(*Var); v != nil && !v.used {
-			check.softErrorf(v.pos, "%s declared but not used", v.name)
+			unused = append(unused, v)
 		}
 	}
+	sort.Sort(byPos(unused))
+	for _, v := range unused {
+		v.used = true // avoid duplicate error when checking enclosing function
+		check.Unused = append(check.Unused, v)
+		check.softErrorf(v.pos, "%s declared but not used", v.name)
+	}
 	for _, scope := range scope.children {
 		check.usage(scope)
 	}
 }
 
+// byPos sorts variables by source position.
+type byPos []*Var
+
+func (a byPos) Len() int           { return len(a) }
+func (a byPos) Less(i, j int) bool { return a[i].pos < a[j].pos }
+func (a byPos) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
 // stmtContext is a bitset describing which
 // control-flow statements are permissible.
 type stmtContext uint
@@ -145,6 +161,7 @@ func (check *Checker) suspendedCall(keyword string, call *ast.CallExpr) {
 	case expression:
 		msg = "discards result of"
 	case statement:
+		check.discardResults(call, &x)
 		return
 	default:
 		unreachable()
@@ -152,6 +169,24 @@ func (check *Checker) suspendedCall(keyword string, call *ast.CallExpr) {
 	check.errorf(x.pos(), "%s %s %s", keyword, msg, &x)
 }
 
+// discardResults records all results of e as unused if e is a
+// (possibly parenthesized) call; x is the operand for e.
+func (check *Checker) discardResults(e ast.Expr, x *operand) {
+	call, _ := unparen(e).(*ast.CallExpr)
+	if call == nil || x.mode == invalid || x.mode == novalue {
+		return
+	}
+	n := 1
+	if t, _ := x.typ.(*Tuple); t != nil {
+		n = t.Len()
+	}
+	unused := make([]bool, n)
+	for i := range unused {
+		unused[i] = true
+	}
+	check.recordUnusedResults(call, unused)
+}
+
 func (check *Checker) caseValues(x operand /* copy argument (not *operand!) */, values []ast.Expr) {
 	// No duplicate checking for now. See issue 4524.
 	for _, e := range values {
@@ -193,6 +228,7 @@ L:
 		}
 		seen[T] = e.Pos()
 		if T != nil {
+			check.recordAssertion(e, x.typ, T)
 			check.typeAssertion(e.Pos(), x, xtyp, T)
 		}
 	}
@@ -238,6 +274,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 		switch x.mode {
 		default:
 			if kind == statement {
+				check.discardResults(s.X, &x)
 				return
 			}
 			msg = "is not used"
@@ -457,6 +494,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				return
 			}
 			check.recordDef(lhs, nil) // lhs variable is implicitly declared in each cause clause
+			check.reportShadowing(lhs.Pos(), lhs.Name)
 
 			rhs = guard.Rhs[0]
 
@@ -506,7 +544,11 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 					T = x.typ
 				}
 				obj := NewVar(lhs.Pos(), check.pkg, lhs.Name, T)
-				check.declare(check.scope, nil, obj)
+				// The case scope is new and obj cannot be redeclared; don't use
+				// check.declare so that shadowing is only reported once, above.
+				if obj.name != "_" {
+					check.scope.Insert(obj)
+				}
 				check.recordImplicit(clause, obj)
 				// For the "declared but not used" error, all lhs variables act as
 				// one; i.e., if any one of them is 'used', all of them are 'used'.
@@ -527,6 +569,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 				v.used = true // avoid usage error when checking entire function
 			}
 			if !used {
+				check.Unused = append(check.Unused, lhsVars...)
 				check.softErrorf(lhs.Pos(), "%s declared but not used", lhs.Name)
 			}
 		}
@@ -683,6 +726,7 @@ func (check *Checker) stmt(ctxt stmtContext, s ast.Stmt) {
 					// declare new variable
 					name := ident.Name
 					obj = NewVar(ident.Pos(), check.pkg, name, nil)
+					obj.inferred = true
 					check.recordDef(ident, obj)
 					// _ variables don't count as new variables
 					if name != "_" {
@@ -4,7 +4,11 @@
 
 package types
 
-import "sort"
+import (
+	"fmt"
+	"go/token"
+	"sort"
+)
 
 // TODO(gri) Revisit factory functions - make sure they have all relevant parameters.
 
@@ -12,6 +16,12 @@ import "sort"
 // All types implement the Type interface.
 type Type interface {
 	// Underlying returns the underlying type of a type.
+	// Per the spec, the underlying type of a named type is never a
+	// named type itself: for type A B; type B int the underlying type
+	// of A is int, not B. This holds for all types produced by the
+	// type checker and by NewNamed and SetUnderlying, with the single
+	// exception that a *Named whose underlying type has not been set
+	// yet returns nil (see also UnderlyingBase).
 	Underlying() Type
 
 	// String returns a string representation of a type.
@@ -94,16 +104,25 @@ func (b *Basic) Name() string { return b.name }
 
 // An Array represents an array type.
 type Array struct {
-	len  int64
-	elem Type
+	len      int64
+	elem     Type
+	implicit bool // length inferred from a composite literal of type [...]T
 }
 
 // NewArray returns a new array type for the given element type and length.
-func NewArray(elem Type, len int64) *Array { return &Array{len, elem} }
+func NewArray(elem Type, len int64) *Array { return &Array{len: len, elem: elem} }
 
-// Len returns the length of array a.
+// Len returns the length of array a. For an array type [...]T of a
+// composite literal, the length is the one determined from the
+// literal's elements. For an array type with an invalid length
+// expression (for which an error was reported), the length is 0.
 func (a *Array) Len() int64 { return a.len }
 
+// Implicit reports whether the length of array a was inferred from
+// the elements of a composite literal of type [...]T rather than
+// given explicitly. Implicitness does not affect type identity.
+func (a *Array) Implicit() bool { return a.implicit }
+
 // Elem returns element type of array a.
 func (a *Array) Elem() Type { return a.elem }
 
@@ -171,6 +190,13 @@ func (p *Pointer) Elem() Type { return p.base }
 // A Tuple represents an ordered list of variables; a nil *Tuple is a valid (empty) tuple.
 // Tuples are used as components of signatures and to represent the type of multiple
 // assignments; they are not first class types of Go.
+//
+// The variables of a tuple may be named or unnamed (with an empty name). The
+// parameters and results of signatures created by the type checker are named
+// if (and only if) they are named in the source, including blank (_) names;
+// the tuples recorded for multi-valued expressions such as comma-ok expressions
+// consist of unnamed variables. The string form of a tuple lists the names of
+// named variables before their types, as in (n int, err error) or (string, bool).
 type Tuple struct {
 	vars []*Var
 }
@@ -191,7 +217,8 @@ func (t *Tuple) Len() int {
 	return 0
 }
 
-// At returns the i'th variable of tuple t.
+// At returns the i'th variable of tuple t; its Name and Type methods
+// provide the component's name (possibly empty) and type.
 func (t *Tuple) At(i int) *Var { return t.vars[i] }
 
 // A Signature represents a (non-builtin) function or method type.
@@ -206,7 +233,9 @@ type Signature struct {
 // NewSignature returns a new function type for the given receiver, parameters,
 // and results, either of which may be nil. If variadic is set, the function
 // is variadic, it must have at least one parameter, and the last parameter
-// must be of unnamed slice type.
+// must be of unnamed slice type: for a final parameter ...T, the type of the
+// parameter variable is NewSlice(T). NewSignature panics if these conditions
+// are not met.
 func NewSignature(scope *Scope, recv *Var, params, results *Tuple, variadic bool) *Signature {
 	// TODO(gri) Should we rely on the correct (non-nil) incoming scope
 	//           or should this function allocate and populate a scope?
@@ -215,13 +244,38 @@ func NewSignature(scope *Scope, recv *Var, params, results *Tuple, variadic bool
 		if n == 0 {
 			panic("types.NewSignature: variadic function must have at least one parameter")
 		}
-		if _, ok := params.At(n - 1).typ.(*Slice); !ok {
-			panic("types.NewSignature: variadic parameter must be of unnamed slice type")
+		if last := params.At(n - 1).typ; !isUnnamedSlice(last) {
+			panic(fmt.Sprintf("types.NewSignature: variadic parameter must be of unnamed slice type, not %s", last))
 		}
 	}
 	return &Signature{scope, recv, params, results, variadic}
 }
 
+// MakeSignature returns a new function type (without receiver) with
+// unnamed parameters and results of the given types. As for NewSignature,
+// if variadic is set, there must be at least one parameter and the type
+// of the last one must be an unnamed slice type: for a final parameter
+// ...T, the respective entry in params is NewSlice(T). MakeSignature
+// panics if these conditions are not met.
+func MakeSignature(params, results []Type, variadic bool) *Signature {
+	return NewSignature(nil, nil, unnamedTuple(params), unnamedTuple(results), variadic)
+}
+
+// unnamedTuple returns a tuple of unnamed variables of the given types.
+func unnamedTuple(types []Type) *Tuple {
+	vars := make([]*Var, len(types))
+	for i, typ := range types {
+		vars[i] = NewParam(token.NoPos, nil, "", typ)
+	}
+	return NewTuple(vars...)
+}
+
+// isUnnamedSlice reports whether t is an unnamed slice type.
+func isUnnamedSlice(t Type) bool {
+	_, ok := t.(*Slice)
+	return ok
+}
+
 // Recv returns the receiver of signature s (if a method), or nil if a
 // function.
 //
@@ -234,11 +288,32 @@ func (s *Signature) Recv() *Var { return s.recv }
 func (s *Signature) Params() *Tuple { return s.params }
 
 // Results returns the results of signature s, or nil.
+//
+// For signatures of functions, methods, and function literals created
+// by the type checker, named results (including blank ones) are the
+// *Var objects recorded in Info.Defs for their declaring identifiers,
+// and they carry the identifiers' positions. Non-blank named results
+// are declared in the function scope and thus visible in the function
+// body. The same holds for named parameters and receivers.
 func (s *Signature) Results() *Tuple { return s.results }
 
 // Variadic reports whether the signature s is variadic.
 func (s *Signature) Variadic() bool { return s.variadic }
 
+// VariadicElem returns the element type T of the final parameter ...T
+// of signature s and true if s is variadic; otherwise it returns nil
+// and false. For the signature of a call of the built-in append with
+// a string argument followed by ..., the element type is byte.
+func (s *Signature) VariadicElem() (Type, bool) {
+	if !s.variadic {
+		return nil, false
+	}
+	if t, ok := s.params.At(s.params.Len() - 1).typ.(*Slice); ok {
+		return t.elem, true
+	}
+	return UniverseByte, true // append([]byte, string...)
+}
+
 // An Interface represents an interface type.
 type Interface struct {
 	methods   []*Func  // ordered list of explicitly declared methods
@@ -248,6 +323,16 @@ type Interface struct {
 }
 
 // NewInterface returns a new interface for the given methods and embedded types.
+// The methods and embedded types are sorted by their unique Id (see Object.Id)
+// in place, which is the same order used for interfaces declared in source.
+//
+// If all embedded types are fully defined interfaces, the interface's method
+// set is computed right away (as by Complete) and the result behaves like an
+// interface declared in source. Otherwise (for instance, if an importer
+// creates an interface embedding a named type whose underlying type is not
+// yet known), Complete must be called once the embedded types are defined.
+// In the former case, NewInterface panics if methods with the same name have
+// different signatures; the interface is not completed.
 func NewInterface(methods []*Func, embeddeds []*Named) *Interface {
 	typ := new(Interface)
 
@@ -263,15 +348,37 @@ func NewInterface(methods []*Func, embeddeds []*Named) *Interface {
 	}
 	sort.Sort(byUniqueMethodName(methods))
 
-	if embeddeds == nil {
+	if embeddeds != nil {
 		sort.Sort(byUniqueTypeName(embeddeds))
 	}
 
 	typ.methods = methods
 	typ.embeddeds = embeddeds
+
+	if typ.completable() {
+		if err := CompleteInterface(typ); err != nil {
+			panic("types.NewInterface: " + err.Error())
+		}
+	}
 	return typ
 }
 
+// completable reports whether all types embedded in t, directly or
+// indirectly, are defined interfaces, i.e., whether t.Complete may
+// be called.
+func (t *Interface) completable() bool {
+	if t.allMethods != nil {
+		return true
+	}
+	for _, et := range t.embeddeds {
+		it, _ := et.underlying.(*Interface)
+		if it == nil || !it.completable() {
+			return false
+		}
+	}
+	return true
+}
+
 // NumExplicitMethods returns the number of explicitly declared methods of interface t.
 func (t *Interface) NumExplicitMethods() int { return len(t.methods) }
 
@@ -300,6 +407,12 @@ func (t *Interface) Empty() bool { return len(t.allMethods) == 0 }
 // NewInterface after the interface's embedded types are fully defined and
 // before using the interface type in any way other than to form other types.
 // Complete returns the receiver.
+//
+// A method that is embedded more than once (for instance, because two embedded
+// interfaces embed the same interface) appears only once in the method set.
+// Complete panics if methods with the same name have different signatures or
+// if an embedded type is not an interface; CompleteInterface reports an error
+// instead.
 func (t *Interface) Complete() *Interface {
 	if t.allMethods != nil {
 		return t
@@ -313,11 +426,24 @@ func (t *Interface) Complete() *Interface {
 			allMethods = t.methods
 		}
 	} else {
+		var mset objset
+		for _, m := range t.methods {
+			mset.insert(m)
+		}
 		allMethods = append(allMethods, t.methods...)
 		for _, et := range t.embeddeds {
-			it := et.Underlying().(*Interface)
+			it, _ := et.Underlying().(*Interface)
+			if it == nil {
+				panic(fmt.Sprintf("types.Interface.Complete: interface contains embedded non-interface %s", et))
+			}
 			it.Complete()
 			for _, tm := range it.allMethods {
+				if alt := mset.insert(tm); alt != nil {
+					if !Identical(alt.Type(), tm.typ) {
+						panic(fmt.Sprintf("types.Interface.Complete: duplicate method %s with different signatures %s and %s", tm.name, alt.Type(), tm.typ))
+					}
+					continue // method already present
+				}
 				// Make a copy of the method and adjust its receiver type.
 				newm := *tm
 				newmtyp := *tm.typ.(*Signature)
@@ -333,6 +459,40 @@ func (t *Interface) Complete() *Interface {
 	return t
 }
 
+// CompleteInterface is like t.Complete but it first verifies that the
+// embedded types of t, and recursively of the interfaces embedded in t,
+// are (fully defined) interfaces and that methods with the same name have
+// identical signatures. Methods with the same name and identical signatures
+// are permitted and appear only once in the method set of t; methods with
+// the same name but different signatures are reported as an error. If an
+// error is reported, t is not completed.
+func CompleteInterface(t *Interface) error {
+	mset := make(map[string]*Func)
+	for _, m := range t.methods {
+		mset[m.Id()] = m
+	}
+	for _, et := range t.embeddeds {
+		it, _ := et.Underlying().(*Interface)
+		if it == nil {
+			return fmt.Errorf("interface contains embedded non-interface %s", et)
+		}
+		if err := CompleteInterface(it); err != nil {
+			return err
+		}
+		for _, m := range it.allMethods {
+			if alt := mset[m.Id()]; alt != nil {
+				if !Identical(alt.typ, m.typ) {
+					return fmt.Errorf("duplicate method %s with different signatures %s and %s", m.name, alt.typ, m.typ)
+				}
+				continue
+			}
+			mset[m.Id()] = m
+		}
+	}
+	t.Complete()
+	return nil
+}
+
 // A Map represents a map type.
 type Map struct {
 	key, elem Type
@@ -376,6 +536,22 @@ func (c *Chan) Dir() ChanDir { return c.dir }
 // Elem returns the element type of channel c.
 func (c *Chan) Elem() Type { return c.elem }
 
+// CanSend reports whether values may be sent on channel c.
+func (c *Chan) CanSend() bool { return c.dir != RecvOnly }
+
+// CanRecv reports whether values may be received from channel c.
+func (c *Chan) CanRecv() bool { return c.dir != SendOnly }
+
+// ChanElemFor returns the element type and direction of the channel
+// type underlying t, and true; if t is not a (possibly named) channel
+// type, the result is nil, SendRecv, and false.
+func ChanElemFor(t Type) (Type, ChanDir, bool) {
+	if c, _ := t.Underlying().(*Chan); c != nil {
+		return c.elem, c.dir, true
+	}
+	return nil, SendRecv, false
+}
+
 // A Named represents a named type.
 type Named struct {
 	obj        *TypeName // corresponding declared object
@@ -385,6 +561,16 @@ type Named struct {
 
 // NewNamed returns a new named type for the given type name, underlying type, and associated methods.
 // The underlying type must not be a *Named.
+//
+// The underlying type may be nil, in which case it must be set with
+// SetUnderlying before t is used; this permits the construction of
+// recursive types. Named types are constructed in the following order:
+// NewTypeName, NewNamed (which also sets the type name's type if it
+// was nil), construction of the underlying type (that may refer to the
+// named type), SetUnderlying, and finally AddMethod for each method.
+// Until the underlying type is set, Underlying returns nil; such a type
+// has no fields, and it is identical, assignable, and convertible only
+// to itself.
 func NewNamed(obj *TypeName, underlying Type, methods []*Func) *Named {
 	if _, ok := underlying.(*Named); ok {
 		panic("types.NewNamed: underlying type must not be *Named")
@@ -403,9 +589,16 @@ func (t *Named) Obj() *TypeName { return t.obj }
 func (t *Named) NumMethods() int { return len(t.methods) }
 
 // Method returns the i'th method of named type t for 0 <= i < t.NumMethods().
+// Methods are listed in declaration order: in the order of the files presented
+// to the type checker and, within a file, in source order. Methods declared in
+// later calls of Checker.Files, or added via AddMethod, follow the methods
+// declared before. The order does not depend on map iteration and is stable
+// across runs.
 func (t *Named) Method(i int) *Func { return t.methods[i] }
 
 // SetUnderlying sets the underlying type and marks t as complete.
+// The underlying type must not be nil or a *Named, and it must not
+// have been set already to a different type.
 // TODO(gri) determine if there's a better solution rather than providing this function
 func (t *Named) SetUnderlying(underlying Type) {
 	if underlying == nil {
@@ -414,6 +607,9 @@ func (t *Named) SetUnderlying(underlying Type) {
 	if _, ok := underlying.(*Named); ok {
 		panic("types.Named.SetUnderlying: underlying type must not be *Named")
 	}
+	if t.underlying != nil && t.underlying != underlying {
+		panic("types.Named.SetUnderlying: underlying type already set")
+	}
 	t.underlying = underlying
 }
 
@@ -439,6 +635,23 @@ func (t *Map) Underlying() Type       { return t }
 func (t *Chan) Underlying() Type      { return t }
 func (t *Named) Underlying() Type     { return t.underlying }
 
+// UnderlyingBase returns the structural base type of t: it applies
+// Underlying repeatedly until the result is not a *Named type. Since
+// Named.Underlying already returns the fully resolved underlying type
+// for complete types (see Type.Underlying), UnderlyingBase(t) is the
+// same as t.Underlying() in that case; unlike the latter, UnderlyingBase
+// remains correct for Type implementations and partially constructed
+// types that do not maintain this invariant. The result is nil if the
+// underlying type of a named type is not set.
+func UnderlyingBase(t Type) Type {
+	for {
+		t = t.Underlying()
+		if _, ok := t.(*Named); !ok {
+			return t // possibly nil
+		}
+	}
+}
+
 func (t *Basic) String() string     { return TypeString(nil, t) }
 func (t *Array) String() string     { return TypeString(nil, t) }
 func (t *Slice) String() string     { return TypeString(nil, t) }
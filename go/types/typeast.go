@@ -0,0 +1,222 @@
+// Copyright 2015 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the conversion of types into
+// type expressions (syntax trees).
+
+package types
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"strconv"
+)
+
+// TypeExpr returns a type expression (syntax tree) denoting t, suitable
+// for generating Go source. Named types declared in packages other than
+// pkg are qualified by their package name; it is the caller's responsibility
+// to make these names refer to the respective packages (via imports) in the
+// generated code. If pkg is nil, all named types (other than predeclared
+// ones) are qualified.
+//
+// Untyped basic types are replaced by their default types. Types that
+// cannot be denoted in Go source (invalid types, the untyped nil type,
+// and tuples) are represented by an *ast.BadExpr, as is the final
+// parameter string... of the signature func([]byte, string...) recorded
+// for calls of the built-in append with a string argument.
+//
+// The resulting syntax tree carries no position information.
+func TypeExpr(pkg *Package, t Type) ast.Expr {
+	switch t := t.(type) {
+	case *Basic:
+		if t.kind == UnsafePointer {
+			return qualifiedIdent(pkg, Unsafe, "Pointer")
+		}
+		if t.info&IsUntyped != 0 {
+			t = Default(t).(*Basic)
+		}
+		if t.kind == Invalid || t.kind == UntypedNil {
+			return &ast.BadExpr{}
+		}
+		return ast.NewIdent(t.name)
+
+	case *Array:
+		return &ast.ArrayType{
+			Len: &ast.BasicLit{Kind: token.INT, Value: strconv.FormatInt(t.len, 10)},
+			Elt: TypeExpr(pkg, t.elem),
+		}
+
+	case *Slice:
+		return &ast.ArrayType{Elt: TypeExpr(pkg, t.elem)}
+
+	case *Struct:
+		var list []*ast.Field
+		for i, f := range t.fields {
+			fld := &ast.Field{Type: TypeExpr(pkg, f.typ)}
+			if !f.anonymous {
+				fld.Names = []*ast.Ident{ast.NewIdent(f.name)}
+			}
+			if tag := t.Tag(i); tag != "" {
+				fld.Tag = &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(tag)}
+			}
+			list = append(list, fld)
+		}
+		return &ast.StructType{Fields: &ast.FieldList{List: list}}
+
+	case *Pointer:
+		return &ast.StarExpr{X: TypeExpr(pkg, t.base)}
+
+	case *Tuple:
+		return &ast.BadExpr{}
+
+	case *Signature:
+		return funcTypeExpr(pkg, t)
+
+	case *Interface:
+		var list []*ast.Field
+		for _, typ := range t.embeddeds {
+			list = append(list, &ast.Field{Type: TypeExpr(pkg, typ)})
+		}
+		for _, m := range t.methods {
+			list = append(list, &ast.Field{
+				Names: []*ast.Ident{ast.NewIdent(m.name)},
+				Type:  funcTypeExpr(pkg, m.typ.(*Signature)),
+			})
+		}
+		return &ast.InterfaceType{Methods: &ast.FieldList{List: list}}
+
+	case *Map:
+		return &ast.MapType{Key: TypeExpr(pkg, t.key), Value: TypeExpr(pkg, t.elem)}
+
+	case *Chan:
+		var dir ast.ChanDir
+		switch t.dir {
+		case SendRecv:
+			dir = ast.SEND | ast.RECV
+		case SendOnly:
+			dir = ast.SEND
+		case RecvOnly:
+			dir = ast.RECV
+		}
+		elem := TypeExpr(pkg, t.elem)
+		// chan (<-chan T) must be parenthesized; otherwise
+		// it would be parsed as chan<- chan T.
+		if c, _ := t.elem.(*Chan); c != nil && c.dir == RecvOnly && t.dir == SendRecv {
+			elem = &ast.ParenExpr{X: elem}
+		}
+		return &ast.ChanType{Dir: dir, Value: elem}
+
+	case *Named:
+		obj := t.obj
+		if obj.pkg == nil || obj.pkg == pkg {
+			return ast.NewIdent(obj.name)
+		}
+		return qualifiedIdent(pkg, obj.pkg, obj.name)
+	}
+
+	return &ast.BadExpr{}
+}
+
+// ZeroExpr returns an expression (syntax tree) denoting the zero value
+// of type t, suitable for generating Go source: false for booleans, 0 for
+// numeric types, "" for strings, nil for pointer, function, slice, map,
+// channel, and interface types (including unsafe.Pointer), and an empty
+// composite literal T{} for struct and array types T. Types are denoted
+// as by TypeExpr(pkg, t); in particular, named struct and array types
+// are qualified by their package name if they are not declared in pkg.
+// The result is an *ast.BadExpr for invalid types and tuples.
+func ZeroExpr(pkg *Package, t Type) ast.Expr {
+	switch u := t.Underlying().(type) {
+	case *Basic:
+		switch {
+		case u.kind == Invalid:
+			return &ast.BadExpr{}
+		case u.info&IsBoolean != 0:
+			return ast.NewIdent("false")
+		case u.info&IsNumeric != 0:
+			return &ast.BasicLit{Kind: token.INT, Value: "0"}
+		case u.info&IsString != 0:
+			return &ast.BasicLit{Kind: token.STRING, Value: `""`}
+		}
+		return ast.NewIdent("nil") // unsafe.Pointer, untyped nil
+
+	case *Struct, *Array:
+		return &ast.CompositeLit{Type: TypeExpr(pkg, t)}
+
+	case *Pointer, *Signature, *Slice, *Map, *Chan, *Interface:
+		return ast.NewIdent("nil")
+	}
+
+	return &ast.BadExpr{}
+}
+
+// TypeExprString returns the Go source representation of the type
+// expression produced by TypeExpr(pkg, t). Unless the result contains
+// an *ast.BadExpr, it can be parsed back with go/parser.ParseExpr.
+func TypeExprString(pkg *Package, t Type) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, token.NewFileSet(), TypeExpr(pkg, t))
+	return buf.String()
+}
+
+// qualifiedIdent returns the expression denoting the object
+// with the given name declared in package obj as seen from pkg.
+func qualifiedIdent(pkg, obj *Package, name string) ast.Expr {
+	if obj == pkg {
+		return ast.NewIdent(name)
+	}
+	return &ast.SelectorExpr{X: ast.NewIdent(obj.name), Sel: ast.NewIdent(name)}
+}
+
+// funcTypeExpr returns the function type expression for sig.
+// The receiver, if any, is ignored.
+func funcTypeExpr(pkg *Package, sig *Signature) *ast.FuncType {
+	params := tupleFieldList(pkg, sig.params, sig.variadic)
+	if params == nil {
+		params = new(ast.FieldList)
+	}
+	return &ast.FuncType{
+		Params:  params,
+		Results: tupleFieldList(pkg, sig.results, false),
+	}
+}
+
+// tupleFieldList returns the parameter list for the tuple t, or nil if
+// t is empty. Parameter names are used only if all parameters are named.
+// If variadic is set, the last parameter is printed with a ... prefix.
+func tupleFieldList(pkg *Package, t *Tuple, variadic bool) *ast.FieldList {
+	if t.Len() == 0 {
+		return nil
+	}
+	named := true
+	for _, v := range t.vars {
+		if v.name == "" {
+			named = false
+			break
+		}
+	}
+	var list []*ast.Field
+	for i, v := range t.vars {
+		var typ ast.Expr
+		if variadic && i == len(t.vars)-1 {
+			if s, ok := v.typ.(*Slice); ok {
+				typ = &ast.Ellipsis{Elt: TypeExpr(pkg, s.elem)}
+			} else {
+				// special case:
+				// append(s, "foo"...) leads to signature func([]byte, string...)
+				typ = &ast.BadExpr{}
+			}
+		} else {
+			typ = TypeExpr(pkg, v.typ)
+		}
+		fld := &ast.Field{Type: typ}
+		if named {
+			fld.Names = []*ast.Ident{ast.NewIdent(v.name)}
+		}
+		list = append(list, fld)
+	}
+	return &ast.FieldList{List: list}
+}
@@ -9,6 +9,7 @@ package types
 import (
 	"bytes"
 	"fmt"
+	"sort"
 )
 
 // If GcCompatibilityMode is set, printing of types is modified
@@ -40,10 +41,32 @@ func TypeString(this *Package, typ Type) string {
 // Named types are printed package-qualified if they
 // do not belong to this package.
 func WriteType(buf *bytes.Buffer, this *Package, typ Type) {
-	writeType(buf, this, typ, make([]Type, 8))
+	writeType(buf, this, false, typ, make([]Type, 8))
 }
 
-func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
+// TypeStringSorted is like TypeString but prints the fields of struct
+// types sorted (see WriteTypeSorted).
+func TypeStringSorted(this *Package, typ Type) string {
+	var buf bytes.Buffer
+	WriteTypeSorted(&buf, this, typ)
+	return buf.String()
+}
+
+// WriteTypeSorted is like WriteType but writes the fields of struct
+// types sorted by name (the name of an embedded field is its type name)
+// rather than in declaration order. This is useful for comparing struct
+// types regardless of field order, e.g. in golden tests. Sorting is for
+// presentation only: struct types with differently ordered fields are
+// not identical (see Identical), even though they print the same.
+//
+// With both WriteType and WriteTypeSorted, interface types are written
+// with their methods sorted as for (*Interface).Method, independent of
+// declaration order.
+func WriteTypeSorted(buf *bytes.Buffer, this *Package, typ Type) {
+	writeType(buf, this, true, typ, make([]Type, 8))
+}
+
+func writeType(buf *bytes.Buffer, this *Package, sorted bool, typ Type, visited []Type) {
 	// Theoretically, this is a quadratic lookup algorithm, but in
 	// practice deeply nested composite types with unnamed component
 	// types are uncommon. This code is likely more efficient than
@@ -77,23 +100,31 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 
 	case *Array:
 		fmt.Fprintf(buf, "[%d]", t.len)
-		writeType(buf, this, t.elem, visited)
+		writeType(buf, this, sorted, t.elem, visited)
 
 	case *Slice:
 		buf.WriteString("[]")
-		writeType(buf, this, t.elem, visited)
+		writeType(buf, this, sorted, t.elem, visited)
 
 	case *Struct:
 		buf.WriteString("struct{")
-		for i, f := range t.fields {
-			if i > 0 {
+		order := make([]int, len(t.fields))
+		for i := range order {
+			order[i] = i
+		}
+		if sorted {
+			sort.Stable(byFieldName{t.fields, order})
+		}
+		for k, i := range order {
+			f := t.fields[i]
+			if k > 0 {
 				buf.WriteString("; ")
 			}
 			if !f.anonymous {
 				buf.WriteString(f.name)
 				buf.WriteByte(' ')
 			}
-			writeType(buf, this, f.typ, visited)
+			writeType(buf, this, sorted, f.typ, visited)
 			if tag := t.Tag(i); tag != "" {
 				fmt.Fprintf(buf, " %q", tag)
 			}
@@ -102,14 +133,14 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 
 	case *Pointer:
 		buf.WriteByte('*')
-		writeType(buf, this, t.base, visited)
+		writeType(buf, this, sorted, t.base, visited)
 
 	case *Tuple:
-		writeTuple(buf, this, t, false, visited)
+		writeTuple(buf, this, sorted, t, false, visited)
 
 	case *Signature:
 		buf.WriteString("func")
-		writeSignature(buf, this, t, visited)
+		writeSignature(buf, this, sorted, t, visited)
 
 	case *Interface:
 		// We write the source-level methods and embedded types rather
@@ -132,7 +163,7 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 					buf.WriteString("; ")
 				}
 				buf.WriteString(m.name)
-				writeSignature(buf, this, m.typ.(*Signature), visited)
+				writeSignature(buf, this, sorted, m.typ.(*Signature), visited)
 			}
 		} else {
 			// print explicit interface methods and embedded types
@@ -141,22 +172,22 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 					buf.WriteString("; ")
 				}
 				buf.WriteString(m.name)
-				writeSignature(buf, this, m.typ.(*Signature), visited)
+				writeSignature(buf, this, sorted, m.typ.(*Signature), visited)
 			}
 			for i, typ := range t.embeddeds {
 				if i > 0 || len(t.methods) > 0 {
 					buf.WriteString("; ")
 				}
-				writeType(buf, this, typ, visited)
+				writeType(buf, this, sorted, typ, visited)
 			}
 		}
 		buf.WriteByte('}')
 
 	case *Map:
 		buf.WriteString("map[")
-		writeType(buf, this, t.key, visited)
+		writeType(buf, this, sorted, t.key, visited)
 		buf.WriteByte(']')
-		writeType(buf, this, t.elem, visited)
+		writeType(buf, this, sorted, t.elem, visited)
 
 	case *Chan:
 		var s string
@@ -179,7 +210,7 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 		if parens {
 			buf.WriteByte('(')
 		}
-		writeType(buf, this, t.elem, visited)
+		writeType(buf, this, sorted, t.elem, visited)
 		if parens {
 			buf.WriteByte(')')
 		}
@@ -204,7 +235,7 @@ func writeType(buf *bytes.Buffer, this *Package, typ Type, visited []Type) {
 	}
 }
 
-func writeTuple(buf *bytes.Buffer, this *Package, tup *Tuple, variadic bool, visited []Type) {
+func writeTuple(buf *bytes.Buffer, this *Package, sorted bool, tup *Tuple, variadic bool, visited []Type) {
 	buf.WriteByte('(')
 	if tup != nil {
 		for i, v := range tup.vars {
@@ -226,12 +257,12 @@ func writeTuple(buf *bytes.Buffer, this *Package, tup *Tuple, variadic bool, vis
 					if t, ok := typ.Underlying().(*Basic); !ok || t.kind != String {
 						panic("internal error: string type expected")
 					}
-					writeType(buf, this, typ, visited)
+					writeType(buf, this, sorted, typ, visited)
 					buf.WriteString("...")
 					continue
 				}
 			}
-			writeType(buf, this, typ, visited)
+			writeType(buf, this, sorted, typ, visited)
 		}
 	}
 	buf.WriteByte(')')
@@ -242,11 +273,11 @@ func writeTuple(buf *bytes.Buffer, this *Package, tup *Tuple, variadic bool, vis
 // Named types are printed package-qualified if they
 // do not belong to this package.
 func WriteSignature(buf *bytes.Buffer, this *Package, sig *Signature) {
-	writeSignature(buf, this, sig, make([]Type, 8))
+	writeSignature(buf, this, false, sig, make([]Type, 8))
 }
 
-func writeSignature(buf *bytes.Buffer, this *Package, sig *Signature, visited []Type) {
-	writeTuple(buf, this, sig.params, sig.variadic, visited)
+func writeSignature(buf *bytes.Buffer, this *Package, sorted bool, sig *Signature, visited []Type) {
+	writeTuple(buf, this, sorted, sig.params, sig.variadic, visited)
 
 	n := sig.results.Len()
 	if n == 0 {
@@ -257,10 +288,20 @@ func writeSignature(buf *bytes.Buffer, this *Package, sig *Signature, visited []
 	buf.WriteByte(' ')
 	if n == 1 && sig.results.vars[0].name == "" {
 		// single unnamed result
-		writeType(buf, this, sig.results.vars[0].typ, visited)
+		writeType(buf, this, sorted, sig.results.vars[0].typ, visited)
 		return
 	}
 
 	// multiple or named result(s)
-	writeTuple(buf, this, sig.results, false, visited)
+	writeTuple(buf, this, sorted, sig.results, false, visited)
 }
+
+// byFieldName sorts a list of field indices by field name.
+type byFieldName struct {
+	fields []*Var
+	order  []int
+}
+
+func (a byFieldName) Len() int           { return len(a.order) }
+func (a byFieldName) Swap(i, j int)      { a.order[i], a.order[j] = a.order[j], a.order[i] }
+func (a byFieldName) Less(i, j int) bool { return a.fields[a.order[i]].name < a.fields[a.order[j]].name }
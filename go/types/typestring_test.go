@@ -156,3 +156,151 @@ func TestQualifiedTypeString(t *testing.T) {
 		}
 	}
 }
+
+func TestSortedTypeString(t *testing.T) {
+	const src = `package p
+type E int
+type S1 struct { b int; E; a string "tag" }
+type S2 struct { a string "tag"; b int; E }
+type I1 interface { n(); M(int); m() }
+type I2 interface { m(); n(); M(int) }
+`
+	pkg, err := makePkg(t, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ := func(name string) Type { return pkg.Scope().Lookup(name).Type().Underlying() }
+
+	// interface methods are always sorted
+	for _, name := range []string{"I1", "I2"} {
+		if got, want := typ(name).String(), "interface{M(int); m(); n()}"; got != want {
+			t.Errorf("%s: got %s; want %s", name, got, want)
+		}
+	}
+
+	// struct fields are sorted only if requested
+	if got, want := typ("S1").String(), `struct{b int; p.E; a string "tag"}`; got != want {
+		t.Errorf("S1: got %s; want %s", got, want)
+	}
+	for _, name := range []string{"S1", "S2"} {
+		if got, want := TypeStringSorted(nil, typ(name)), `struct{p.E; a string "tag"; b int}`; got != want {
+			t.Errorf("%s: got %s; want %s (sorted)", name, got, want)
+		}
+	}
+	if Identical(typ("S1"), typ("S2")) {
+		t.Errorf("structs with different field order are identical")
+	}
+}
+
+func TestTypeExpr(t *testing.T) {
+	for _, test := range independentTestTypes {
+		src := `package p; type T ` + test.src
+		pkg, err := makePkg(t, src)
+		if err != nil {
+			continue // not all test types are valid
+		}
+		typ := pkg.Scope().Lookup("T").Type().Underlying()
+		got := TypeExprString(pkg, typ)
+		if _, err := parser.ParseExpr(got); err != nil {
+			t.Errorf("%s: TypeExprString = %q does not parse: %s", test.src, got, err)
+			continue
+		}
+
+		// the output must denote the same type
+		pkg, err = makePkg(t, src+"; type U "+got)
+		if err != nil {
+			t.Errorf("%s: %s", got, err)
+			continue
+		}
+		T := pkg.Scope().Lookup("T").Type().Underlying()
+		U := pkg.Scope().Lookup("U").Type().Underlying()
+		if !Identical(T, U) {
+			t.Errorf("%s: TypeExprString = %s denotes %s", test.src, got, U)
+		}
+	}
+}
+
+func TestQualifiedTypeExpr(t *testing.T) {
+	p, _ := pkgFor("p.go", "package p; type T int", nil)
+	q, _ := pkgFor("q.go", "package q", nil)
+
+	pT := p.Scope().Lookup("T").Type()
+	s := NewStruct([]*Var{
+		NewField(token.NoPos, q, "T", pT, true),
+		NewField(token.NoPos, q, "f", NewPointer(pT), false),
+		NewField(token.NoPos, q, "u", Typ[UnsafePointer], false),
+	}, []string{"", `json:"f"`})
+	for _, test := range []struct {
+		typ  Type
+		this *Package
+		want string
+	}{
+		{pT, p, "T"},
+		{pT, q, "p.T"},
+		{NewSlice(pT), nil, "[]p.T"},
+		{Typ[UntypedFloat], q, "float64"},
+		{Typ[UntypedRune], q, "rune"},
+		{Typ[UntypedNil], q, "BadExpr"},
+		{s, q, "struct {\n\tp.T\n\tf\t*p.T\t\"json:\\\"f\\\"\"\n\tu\tunsafe.Pointer\n}"},
+	} {
+		if got := TypeExprString(test.this, test.typ); got != test.want {
+			t.Errorf("TypeExprString(%s, %s) = %q, want %q", test.this, test.typ, got, test.want)
+		}
+	}
+}
+
+func TestTypeExprAppendString(t *testing.T) {
+	const src = `package p; var b []byte; var _ = append(b, "foo"...)`
+	info := Info{Types: make(map[ast.Expr]TypeAndValue)}
+	pkg, err := pkgFor("p.go", src, &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for e, tv := range info.Types {
+		if id, _ := e.(*ast.Ident); id != nil && id.Name == "append" {
+			if got, want := TypeExprString(pkg, tv.Type), "func([]byte, BadExpr) []byte"; got != want {
+				t.Errorf("TypeExprString(%s) = %q, want %q", tv.Type, got, want)
+			}
+			return
+		}
+	}
+	t.Fatal("no type recorded for append")
+}
+
+func TestZeroExpr(t *testing.T) {
+	p, _ := pkgFor("p.go", "package p; type S struct{ f int }; type A [2]string; type I int; type F func()", nil)
+	q, _ := pkgFor("q.go", "package q", nil)
+	lookup := func(name string) Type { return p.Scope().Lookup(name).Type() }
+
+	for _, test := range []struct {
+		typ  Type
+		this *Package
+		want string
+	}{
+		{Typ[Bool], q, "false"},
+		{Typ[Int], q, "0"},
+		{Typ[Complex64], q, "0"},
+		{Typ[UntypedFloat], q, "0"},
+		{Typ[String], q, `""`},
+		{Typ[UnsafePointer], q, "nil"},
+		{Typ[UntypedNil], q, "nil"},
+		{NewPointer(Typ[Int]), q, "nil"},
+		{NewSlice(Typ[Int]), q, "nil"},
+		{NewMap(Typ[String], Typ[Int]), q, "nil"},
+		{NewChan(SendRecv, Typ[Int]), q, "nil"},
+		{NewInterface(nil, nil).Complete(), q, "nil"},
+		{NewArray(Typ[Int], 3), q, "[3]int{}"},
+		{NewStruct(nil, nil), q, "struct{}{}"},
+		{lookup("S"), p, "S{}"},
+		{lookup("S"), q, "p.S{}"},
+		{lookup("A"), q, "p.A{}"},
+		{lookup("I"), q, "0"},
+		{lookup("F"), q, "nil"},
+		{Typ[Invalid], q, "(bad expr)"},
+		{NewTuple(), q, "(bad expr)"},
+	} {
+		if got := ExprString(ZeroExpr(test.this, test.typ)); got != test.want {
+			t.Errorf("ZeroExpr(%s, %s) = %s; want %s", test.this, test.typ, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines a comparison of the exported APIs of two packages.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/exact"
+	"golang.org/x/tools/go/types"
+)
+
+// A ChangeKind describes the kind of an API change.
+type ChangeKind int
+
+const (
+	Added   ChangeKind = iota // exported object added
+	Removed                   // exported object removed
+	Changed                   // exported object changed
+)
+
+var changeKindNames = [...]string{
+	Added:   "added",
+	Removed: "removed",
+	Changed: "changed",
+}
+
+func (k ChangeKind) String() string { return changeKindNames[k] }
+
+// A Change describes a difference between the exported APIs of two
+// versions of a package, as reported by APIDiff.
+type Change struct {
+	Name     string       // object name; "T.m" for a method or field m of type T
+	Kind     ChangeKind   // kind of change
+	Old, New types.Object // old and new object; nil if added or removed, respectively
+	Breaking bool         // set if the change may break existing clients
+}
+
+func (c Change) String() string {
+	s := c.Name + ": " + c.Kind.String()
+	if c.Breaking {
+		s += " (breaking)"
+	}
+	if c.Old != nil && c.New != nil {
+		s += fmt.Sprintf(": %s -> %s", types.ObjectString(c.Old.Pkg(), c.Old), types.ObjectString(c.New.Pkg(), c.New))
+	}
+	return s
+}
+
+// APIDiff compares the exported package-level objects of two versions
+// of a package, old and new, by name and returns the list of changes,
+// sorted by name. Exported fields of struct types and exported methods
+// of named types are compared as well. Types are compared structurally;
+// since the named types of the two packages are distinct objects and thus
+// never Identical, named types correspond if they have the same name and
+// are declared in old and new, respectively, or in the same package.
+//
+// Removed objects and changes of an object's kind or type are breaking.
+// Added objects, fields, and methods are not breaking, except for
+// methods added to an interface type (which existing implementations
+// lack). A changed constant value is reported as a non-breaking change.
+//
+func APIDiff(old, new *types.Package) []Change {
+	d := apiDiff{old: old, new: new}
+	for _, name := range exportedNames(old.Scope(), new.Scope()) {
+		o, n := old.Scope().Lookup(name), new.Scope().Lookup(name)
+		switch {
+		case n == nil:
+			d.report(name, Removed, o, nil, true)
+		case o == nil:
+			d.report(name, Added, nil, n, false)
+		default:
+			d.object(name, o, n)
+		}
+	}
+	sort.Sort(byChangeName(d.changes))
+	return d.changes
+}
+
+type apiDiff struct {
+	old, new *types.Package
+	changes  []Change
+}
+
+func (d *apiDiff) report(name string, kind ChangeKind, old, new types.Object, breaking bool) {
+	d.changes = append(d.changes, Change{name, kind, old, new, breaking})
+}
+
+// identical reports whether the old type x and the new type y are the
+// same. Types are compared structurally, as by types.Identical, except
+// that named types (and unexported field and method names) correspond if
+// they are declared in corresponding packages: the old and the new package,
+// or packages with the same path. Parameter and result names, and the
+// receivers of signatures, are ignored.
+func (d *apiDiff) identical(x, y types.Type) bool {
+	switch x := x.(type) {
+	case *types.Basic:
+		if y, ok := y.(*types.Basic); ok {
+			return x.Kind() == y.Kind()
+		}
+
+	case *types.Array:
+		if y, ok := y.(*types.Array); ok {
+			return x.Len() == y.Len() && d.identical(x.Elem(), y.Elem())
+		}
+
+	case *types.Slice:
+		if y, ok := y.(*types.Slice); ok {
+			return d.identical(x.Elem(), y.Elem())
+		}
+
+	case *types.Struct:
+		if y, ok := y.(*types.Struct); ok && x.NumFields() == y.NumFields() {
+			for i := 0; i < x.NumFields(); i++ {
+				f, g := x.Field(i), y.Field(i)
+				if f.Anonymous() != g.Anonymous() || x.Tag(i) != y.Tag(i) ||
+					!d.sameName(f, g) || !d.identical(f.Type(), g.Type()) {
+					return false
+				}
+			}
+			return true
+		}
+
+	case *types.Pointer:
+		if y, ok := y.(*types.Pointer); ok {
+			return d.identical(x.Elem(), y.Elem())
+		}
+
+	case *types.Tuple:
+		if y, ok := y.(*types.Tuple); ok && x.Len() == y.Len() {
+			for i := 0; i < x.Len(); i++ {
+				if !d.identical(x.At(i).Type(), y.At(i).Type()) {
+					return false
+				}
+			}
+			return true
+		}
+
+	case *types.Signature:
+		if y, ok := y.(*types.Signature); ok {
+			return x.Variadic() == y.Variadic() &&
+				d.identical(x.Params(), y.Params()) &&
+				d.identical(x.Results(), y.Results())
+		}
+
+	case *types.Interface:
+		if y, ok := y.(*types.Interface); ok && x.NumMethods() == y.NumMethods() {
+			for i := 0; i < x.NumMethods(); i++ {
+				f, g := x.Method(i), y.Method(i)
+				if !d.sameName(f, g) || !d.identical(f.Type(), g.Type()) {
+					return false
+				}
+			}
+			return true
+		}
+
+	case *types.Map:
+		if y, ok := y.(*types.Map); ok {
+			return d.identical(x.Key(), y.Key()) && d.identical(x.Elem(), y.Elem())
+		}
+
+	case *types.Chan:
+		if y, ok := y.(*types.Chan); ok {
+			return x.Dir() == y.Dir() && d.identical(x.Elem(), y.Elem())
+		}
+
+	case *types.Named:
+		if y, ok := y.(*types.Named); ok {
+			return d.sameName(x.Obj(), y.Obj())
+		}
+	}
+	return false
+}
+
+// sameName reports whether the old object x and the new object y have
+// the same name and, if they are unexported or denote named types,
+// are declared in corresponding packages.
+func (d *apiDiff) sameName(x, y types.Object) bool {
+	if x.Name() != y.Name() {
+		return false
+	}
+	if _, ok := x.(*types.TypeName); !ok && x.Exported() {
+		return true
+	}
+	xpkg, ypkg := x.Pkg(), y.Pkg()
+	if xpkg == nil || ypkg == nil {
+		return xpkg == ypkg // predeclared types such as error
+	}
+	return xpkg == d.old && ypkg == d.new || xpkg.Path() == ypkg.Path()
+}
+
+func (d *apiDiff) object(name string, o, n types.Object) {
+	switch o := o.(type) {
+	case *types.Const:
+		if n, ok := n.(*types.Const); ok {
+			if !d.identical(o.Type(), n.Type()) {
+				d.report(name, Changed, o, n, true)
+			} else if !exact.Compare(o.Val(), token.EQL, n.Val()) {
+				d.report(name, Changed, o, n, false)
+			}
+			return
+		}
+
+	case *types.Var:
+		if n, ok := n.(*types.Var); ok {
+			if !d.identical(o.Type(), n.Type()) {
+				d.report(name, Changed, o, n, true)
+			}
+			return
+		}
+
+	case *types.Func:
+		if n, ok := n.(*types.Func); ok {
+			if !d.identical(o.Type(), n.Type()) {
+				d.report(name, Changed, o, n, true)
+			}
+			return
+		}
+
+	case *types.TypeName:
+		if n, ok := n.(*types.TypeName); ok {
+			d.typeName(name, o, n)
+			return
+		}
+	}
+
+	// different kinds of objects
+	d.report(name, Changed, o, n, true)
+}
+
+func (d *apiDiff) typeName(name string, o, n *types.TypeName) {
+	ou, nu := o.Type().Underlying(), n.Type().Underlying()
+	switch ou := ou.(type) {
+	case *types.Struct:
+		if nu, ok := nu.(*types.Struct); ok {
+			d.fields(name, ou, nu)
+			d.methods(name, o.Type(), n.Type())
+			return
+		}
+
+	case *types.Interface:
+		if nu, ok := nu.(*types.Interface); ok {
+			d.interfaceMethods(name, ou, nu)
+			return
+		}
+
+	default:
+		if d.identical(ou, nu) {
+			d.methods(name, o.Type(), n.Type())
+			return
+		}
+	}
+
+	// different underlying types
+	d.report(name, Changed, o, n, true)
+}
+
+// fields compares the exported fields of the struct types o and n
+// underlying type name.
+func (d *apiDiff) fields(name string, o, n *types.Struct) {
+	index := make(map[string]*types.Var)
+	for i := 0; i < n.NumFields(); i++ {
+		if f := n.Field(i); f.Exported() {
+			index[f.Name()] = f
+		}
+	}
+	for i := 0; i < o.NumFields(); i++ {
+		of := o.Field(i)
+		if !of.Exported() {
+			continue
+		}
+		nf := index[of.Name()]
+		switch {
+		case nf == nil:
+			d.report(name+"."+of.Name(), Removed, of, nil, true)
+		case !d.identical(of.Type(), nf.Type()) || of.Anonymous() != nf.Anonymous():
+			d.report(name+"."+of.Name(), Changed, of, nf, true)
+		}
+		delete(index, of.Name())
+	}
+	for i := 0; i < n.NumFields(); i++ {
+		if f := n.Field(i); index[f.Name()] == f {
+			d.report(name+"."+f.Name(), Added, nil, f, false)
+		}
+	}
+}
+
+// methods compares the exported methods of the (non-interface) named
+// types o and n. A method that moves from the method set of T to that
+// of *T only is a breaking change.
+func (d *apiDiff) methods(name string, o, n types.Type) {
+	oset, nset := types.NewMethodSet(o), types.NewMethodSet(n)
+	opset, npset := types.NewMethodSet(types.NewPointer(o)), types.NewMethodSet(types.NewPointer(n))
+	for i := 0; i < opset.Len(); i++ {
+		om := opset.At(i).Obj()
+		if !om.Exported() {
+			continue
+		}
+		nsel := npset.Lookup(d.new, om.Name())
+		switch {
+		case nsel == nil:
+			d.report(name+"."+om.Name(), Removed, om, nil, true)
+		case !d.identical(om.Type(), nsel.Obj().Type()):
+			d.report(name+"."+om.Name(), Changed, om, nsel.Obj(), true)
+		case oset.Lookup(d.old, om.Name()) != nil && nset.Lookup(d.new, om.Name()) == nil:
+			d.report(name+"."+om.Name(), Changed, om, nsel.Obj(), true)
+		}
+	}
+	for i := 0; i < npset.Len(); i++ {
+		if nm := npset.At(i).Obj(); nm.Exported() && opset.Lookup(d.old, nm.Name()) == nil {
+			d.report(name+"."+nm.Name(), Added, nil, nm, false)
+		}
+	}
+}
+
+// interfaceMethods compares the exported methods of the interface types
+// o and n underlying type name. Added methods are breaking changes since
+// existing implementations of the interface lack them.
+func (d *apiDiff) interfaceMethods(name string, o, n *types.Interface) {
+	for i := 0; i < o.NumMethods(); i++ {
+		om := o.Method(i)
+		if !om.Exported() {
+			continue
+		}
+		nm := lookupMethod(n, om.Name())
+		switch {
+		case nm == nil:
+			d.report(name+"."+om.Name(), Removed, om, nil, true)
+		case !d.identical(om.Type(), nm.Type()):
+			d.report(name+"."+om.Name(), Changed, om, nm, true)
+		}
+	}
+	for i := 0; i < n.NumMethods(); i++ {
+		if nm := n.Method(i); nm.Exported() && lookupMethod(o, nm.Name()) == nil {
+			d.report(name+"."+nm.Name(), Added, nil, nm, true)
+		}
+	}
+}
+
+// lookupMethod returns the method of t with the given name, or nil.
+func lookupMethod(t *types.Interface, name string) *types.Func {
+	for i := 0; i < t.NumMethods(); i++ {
+		if m := t.Method(i); m.Name() == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// exportedNames returns the sorted union of the exported names of scopes a and b.
+func exportedNames(a, b *types.Scope) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, s := range []*types.Scope{a, b} {
+		for _, name := range s.Names() {
+			if ast.IsExported(name) && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+type byChangeName []Change
+
+func (a byChangeName) Len() int           { return len(a) }
+func (a byChangeName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+func (a byChangeName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
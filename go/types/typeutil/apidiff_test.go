@@ -0,0 +1,151 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestAPIDiff(t *testing.T) {
+	const oldSrc = `package p
+
+const C = 1
+const D int = 2
+
+var V int
+
+func F(int) {}
+func G() {}
+
+type S struct {
+	A int
+	B string
+	c int
+}
+
+func (S) M() {}
+func (S) N() {}
+func (S) O() {}
+
+type I interface{ M() }
+
+type K int
+
+type X int
+`
+	const newSrc = `package p
+
+const C = 2
+const D int64 = 2
+
+var V int
+
+func F(string) {}
+func H() {}
+
+type S struct {
+	A int
+	B []byte
+	D bool
+}
+
+func (S) M() {}
+func (*S) N() {}
+func (S) P() {}
+
+type I interface{ M(); N() }
+
+type K string
+
+var X int
+`
+	oldPkg := makePkg(t, "old.go", oldSrc)
+	newPkg := makePkg(t, "new.go", newSrc)
+
+	want := []string{
+		"C: changed: const C untyped int -> const C untyped int",
+		"D: changed (breaking): const D int -> const D int64",
+		"F: changed (breaking): func F(int) -> func F(string)",
+		"G: removed (breaking)",
+		"H: added",
+		"I.N: added (breaking)",
+		"K: changed (breaking): type K int -> type K string",
+		"S.B: changed (breaking): field B string -> field B []byte",
+		"S.D: added",
+		"S.N: changed (breaking): func (S).N() -> func (*S).N()",
+		"S.O: removed (breaking)",
+		"S.P: added",
+		"X: changed (breaking): type X int -> var X int",
+	}
+	changes := typeutil.APIDiff(oldPkg, newPkg)
+	if len(changes) != len(want) {
+		t.Errorf("got %d changes; want %d", len(changes), len(want))
+	}
+	for i, c := range changes {
+		if i < len(want) && c.String() != want[i] {
+			t.Errorf("change %d: got %q; want %q", i, c, want[i])
+		}
+	}
+
+	if changes := typeutil.APIDiff(oldPkg, makePkg(t, "old.go", oldSrc)); len(changes) != 0 {
+		t.Errorf("identical packages: got changes %v", changes)
+	}
+}
+
+func TestAPIDiffStructural(t *testing.T) {
+	const oldSrc = `package p
+
+type T int
+
+var (
+	A struct{ X, Y int }
+	B func(T) error
+	C interface{ m() }
+	D interface{ M(x T) }
+	E map[string]*T
+)
+`
+	const newSrc = `package p
+
+type T int
+
+var (
+	A struct{ Y, X int }
+	B func(t T) error
+	C interface{ n() }
+	D interface{ M(y T) }
+	E map[string]*int
+)
+`
+	changes := typeutil.APIDiff(makePkg(t, "old.go", oldSrc), makePkg(t, "new.go", newSrc))
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.Name)
+	}
+	// reordered struct fields are a breaking change; parameter names don't matter
+	if want := "[A C E]"; fmt.Sprint(got) != want {
+		t.Errorf("got changes %v; want %s", changes, want)
+	}
+}
+
+func makePkg(t *testing.T, filename, src string) *types.Package {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
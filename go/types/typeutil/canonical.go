@@ -0,0 +1,47 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+import "golang.org/x/tools/go/types"
+
+// A Canonicalizer maps types to canonical representatives: identical
+// types (see types.Identical) are mapped to the same types.Type value,
+// which can then be compared with == and used as a Go map key.
+//
+// Named types are identical only to themselves and thus remain distinct;
+// unnamed types such as slices, maps, and signatures that are composed
+// independently, for instance by different type-checker invocations,
+// share a single representative. The representative of a type is the
+// first identical type presented to the Canonicalizer.
+//
+// The zero value is a ready-to-use empty Canonicalizer.
+//
+// Not thread-safe.
+//
+type Canonicalizer struct {
+	types Map // maps each type to its canonical representative
+}
+
+// SetHasher sets the hasher used by the Canonicalizer (see Map.SetHasher).
+func (c *Canonicalizer) SetHasher(hasher Hasher) {
+	c.types.SetHasher(hasher)
+}
+
+// Type returns the canonical representative of T.
+// For example, c.Type(types.NewSlice(elem)) returns the same
+// *types.Slice for all identical element types elem.
+//
+func (c *Canonicalizer) Type(T types.Type) types.Type {
+	if C := c.types.At(T); C != nil {
+		return C.(types.Type)
+	}
+	c.types.Set(T, T)
+	return T
+}
+
+// Len returns the number of canonical types.
+func (c *Canonicalizer) Len() int {
+	return c.types.Len()
+}
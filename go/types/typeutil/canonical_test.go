@@ -0,0 +1,56 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestCanonicalizer(t *testing.T) {
+	var c typeutil.Canonicalizer
+
+	tInt := types.Typ[types.Int]
+	s1 := c.Type(types.NewSlice(tInt))
+	s2 := c.Type(types.NewSlice(tInt))
+	if s1 != s2 {
+		t.Errorf("identical slice types %s and %s not canonicalized", s1, s2)
+	}
+
+	m1 := c.Type(types.NewMap(types.Typ[types.String], s1))
+	m2 := c.Type(types.NewMap(types.Typ[types.String], types.NewSlice(tInt)))
+	if m1 != m2 {
+		t.Errorf("identical map types %s and %s not canonicalized", m1, m2)
+	}
+
+	newSig := func() types.Type {
+		params := types.NewTuple(types.NewVar(token.NoPos, nil, "x", tInt))
+		results := types.NewTuple(types.NewVar(token.NoPos, nil, "", m1))
+		return types.NewSignature(nil, nil, params, results, false)
+	}
+	if f1, f2 := c.Type(newSig()), c.Type(newSig()); f1 != f2 {
+		t.Errorf("identical signatures %s and %s not canonicalized", f1, f2)
+	}
+
+	// Structurally identical named types remain distinct.
+	pkg := types.NewPackage("p", "p")
+	newNamed := func() types.Type {
+		return types.NewNamed(types.NewTypeName(token.NoPos, pkg, "T", nil), tInt, nil)
+	}
+	if n1, n2 := c.Type(newNamed()), c.Type(newNamed()); n1 == n2 {
+		t.Errorf("distinct named types %s and %s canonicalized", n1, n2)
+	}
+
+	if c.Type(types.NewSlice(types.Typ[types.Bool])) == s1 {
+		t.Errorf("different slice types canonicalized")
+	}
+
+	if got, want := c.Len(), 6; got != want {
+		t.Errorf("got %d canonical types; want %d", got, want)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+import "golang.org/x/tools/go/types"
+
+// Elem returns the element type of t and true if the underlying type
+// of t is a pointer, slice, array, channel, or map type; otherwise it
+// returns nil and false. For a map type, the element type is the type
+// of the map's values, not its keys (use (*types.Map).Key for the
+// latter).
+//
+func Elem(t types.Type) (types.Type, bool) {
+	switch t := t.Underlying().(type) {
+	case *types.Pointer:
+		return t.Elem(), true
+	case *types.Slice:
+		return t.Elem(), true
+	case *types.Array:
+		return t.Elem(), true
+	case *types.Chan:
+		return t.Elem(), true
+	case *types.Map:
+		return t.Elem(), true
+	}
+	return nil, false
+}
@@ -0,0 +1,74 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestElem(t *testing.T) {
+	const src = `package p
+type (
+	P *int
+	S []string
+	A [4]bool
+	C <-chan float64
+	M map[string]rune
+	N S
+	X struct{ f int }
+	F func() int
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name string
+		elem string // or "" if none
+	}{
+		{"P", "int"},
+		{"S", "string"},
+		{"A", "bool"},
+		{"C", "float64"},
+		{"M", "rune"},
+		{"N", "string"}, // through named type
+		{"X", ""},
+		{"F", ""},
+	} {
+		T := pkg.Scope().Lookup(test.name).Type()
+		elem, ok := typeutil.Elem(T)
+		if ok != (test.elem != "") {
+			t.Errorf("Elem(%s): got ok = %v", T, ok)
+			continue
+		}
+		if ok && elem.String() != test.elem {
+			t.Errorf("Elem(%s) = %s; want %s", T, elem, test.elem)
+		}
+		if !ok && elem != nil {
+			t.Errorf("Elem(%s) = %s; want nil", T, elem)
+		}
+	}
+
+	// element types of unnamed types
+	if elem, _ := typeutil.Elem(types.NewSlice(types.Typ[types.Uint8])); elem != types.Typ[types.Uint8] {
+		t.Errorf("Elem([]uint8) = %s; want uint8", elem)
+	}
+	if elem, ok := typeutil.Elem(types.Typ[types.String]); ok || elem != nil {
+		t.Errorf("Elem(string) = %s, %v; want nil, false", elem, ok)
+	}
+}
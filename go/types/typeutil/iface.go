@@ -0,0 +1,52 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+// This file defines utilities for interface extraction.
+
+import (
+	"fmt"
+
+	"golang.org/x/tools/go/types"
+)
+
+// MinimalInterface returns the interface consisting of exactly the
+// methods of T with the given names, for use in "extract interface"
+// refactorings. A method is looked up in the method set of T, or, failing
+// that, in the method set of *T; in the latter case only *T implements
+// the result. Promoted methods are found like declared ones.
+//
+// The interface methods have the same names, parameters, and results as
+// the methods of T but no longer T's receiver. Unexported names denote
+// methods of T's package. An error is returned if a name does not denote
+// a method of T or *T, or if a name is repeated.
+//
+func MinimalInterface(T *types.Named, names []string) (*types.Interface, error) {
+	pkg := T.Obj().Pkg()
+	mset := types.NewMethodSet(T)
+	pmset := types.NewMethodSet(types.NewPointer(T))
+
+	var methods []*types.Func
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate method %s", name)
+		}
+		seen[name] = true
+
+		sel := mset.Lookup(pkg, name)
+		if sel == nil {
+			sel = pmset.Lookup(pkg, name)
+		}
+		if sel == nil {
+			return nil, fmt.Errorf("%s has no method %s", T, name)
+		}
+		m := sel.Obj().(*types.Func)
+		sig := m.Type().(*types.Signature)
+		sig = types.NewSignature(nil, nil, sig.Params(), sig.Results(), sig.Variadic())
+		methods = append(methods, types.NewFunc(m.Pos(), m.Pkg(), m.Name(), sig))
+	}
+	return types.NewInterface(methods, nil), nil
+}
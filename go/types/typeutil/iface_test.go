@@ -0,0 +1,72 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestMinimalInterface(t *testing.T) {
+	const src = `package p
+
+type E struct{}
+
+func (E) Close() error { return nil }
+
+type T struct{ E }
+
+func (T) Read(p []byte) (int, error) { return 0, nil }
+func (*T) Write(p []byte) (n int, err error) { return 0, nil }
+func (T) printf(format string, args ...interface{}) {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	T := pkg.Scope().Lookup("T").Type().(*types.Named)
+
+	for _, test := range []struct {
+		names      []string
+		want       string
+		value, ptr bool // whether T and *T implement the result
+	}{
+		{nil, "interface{}", true, true},
+		{[]string{"Read", "Close"}, "interface{Close() error; Read(p []byte) (int, error)}", true, true},
+		{[]string{"Write"}, "interface{Write(p []byte) (n int, err error)}", false, true},
+		{[]string{"printf"}, "interface{printf(format string, args ...interface{})}", true, true},
+	} {
+		iface, err := typeutil.MinimalInterface(T, test.names)
+		if err != nil {
+			t.Errorf("%v: %s", test.names, err)
+			continue
+		}
+		if got := iface.String(); got != test.want {
+			t.Errorf("%v: got %s; want %s", test.names, got, test.want)
+		}
+		if got := types.Implements(T, iface); got != test.value {
+			t.Errorf("%v: T implements %s = %v; want %v", test.names, iface, got, test.value)
+		}
+		if got := types.Implements(types.NewPointer(T), iface); got != test.ptr {
+			t.Errorf("%v: *T implements %s = %v; want %v", test.names, iface, got, test.ptr)
+		}
+	}
+
+	for _, names := range [][]string{{"Missing"}, {"Read", "Read"}} {
+		if _, err := typeutil.MinimalInterface(T, names); err == nil {
+			t.Errorf("%v: got no error", names)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/types"
+)
+
+// An ObjectIndex maps source positions to the identifiers recorded in
+// the Defs and Uses maps of a types.Info, and to the objects they denote.
+// Construction takes O(n log n) time for n identifiers; each ObjectAt
+// query takes O(log n) time.
+//
+// An ObjectIndex does not reflect changes to the Info after it was built.
+//
+type ObjectIndex struct {
+	ids  []*ast.Ident // sorted by position (see byPos)
+	objs map[*ast.Ident]types.Object
+}
+
+// NewObjectIndex returns an ObjectIndex for the identifiers in info.Defs
+// and info.Uses. Identifiers that don't denote an object (such as the
+// package name in a package clause) are not indexed.
+//
+func NewObjectIndex(info *types.Info) *ObjectIndex {
+	x := &ObjectIndex{objs: make(map[*ast.Ident]types.Object)}
+	add := func(m map[*ast.Ident]types.Object) {
+		for id, obj := range m {
+			if obj != nil {
+				if _, found := x.objs[id]; !found {
+					x.ids = append(x.ids, id)
+				}
+				x.objs[id] = obj
+			}
+		}
+	}
+	add(info.Defs)
+	add(info.Uses)
+	sort.Sort(byPos(x.ids))
+	return x
+}
+
+// ObjectAt returns the innermost recorded identifier whose source range
+// [id.Pos(), id.End()) contains pos, and the object it denotes. If there
+// is no such identifier, the result is (nil, nil).
+//
+func (x *ObjectIndex) ObjectAt(pos token.Pos) (types.Object, *ast.Ident) {
+	// find the first identifier starting after pos
+	i := sort.Search(len(x.ids), func(i int) bool { return x.ids[i].Pos() > pos })
+	if i == 0 {
+		return nil, nil
+	}
+	// Identifiers are not nested, but identifiers starting at the same
+	// position (e.g. from different checks sharing a file set) may have
+	// different extents; consider the shortest one containing pos.
+	start := x.ids[i-1].Pos()
+	for i--; i >= 0 && x.ids[i].Pos() == start; i-- {
+		if id := x.ids[i]; pos < id.End() {
+			return x.objs[id], id
+		}
+	}
+	return nil, nil
+}
+
+// byPos sorts identifiers by increasing start position;
+// for equal start positions, longer identifiers come first.
+type byPos []*ast.Ident
+
+func (a byPos) Len() int      { return len(a) }
+func (a byPos) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byPos) Less(i, j int) bool {
+	if a[i].Pos() != a[j].Pos() {
+		return a[i].Pos() < a[j].Pos()
+	}
+	return a[i].End() > a[j].End()
+}
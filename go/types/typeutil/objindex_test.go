@@ -0,0 +1,72 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestObjectIndex(t *testing.T) {
+	const src = `package p
+
+type T struct{ field int }
+
+func (t T) method() int { return t.field + len("x") }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Defs: make(map[*ast.Ident]types.Object),
+		Uses: make(map[*ast.Ident]types.Object),
+	}
+	if _, err := new(types.Config).Check("p", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+	index := typeutil.NewObjectIndex(info)
+
+	file := fset.File(f.Pos())
+	for _, test := range []struct {
+		at   string // position is the offset of the first occurrence of at in src
+		want string // object found at that position, or ""
+	}{
+		{"package", ""},
+		{"p\n", ""}, // package names are not indexed
+		{"T struct", "type p.T struct{field int}"},
+		{"ield int", "field field int"},
+		{" int }", ""},
+		{"int }", "type int int"},
+		{"t T)", "var t p.T"},
+		{"T) method", "type p.T struct{field int}"},
+		{"thod", "func (p.T).method() int"},
+		{"t.field", "var t p.T"},
+		{".field +", ""},
+		{"field +", "field field int"},
+		{"len", "builtin len"},
+		{`"x"`, ""},
+	} {
+		pos := file.Pos(strings.Index(src, test.at))
+		obj, id := index.ObjectAt(pos)
+		var got string
+		if obj != nil {
+			got = obj.String()
+			if id.Pos() > pos || pos >= id.End() {
+				t.Errorf("%q: identifier %s at %s does not contain position", test.at, id.Name, fset.Position(id.Pos()))
+			}
+		}
+		if got != test.want {
+			t.Errorf("%q: got %q; want %q", test.at, got, test.want)
+		}
+	}
+}
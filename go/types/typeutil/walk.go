@@ -0,0 +1,100 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil
+
+import "golang.org/x/tools/go/types"
+
+// WalkType traverses the type graph rooted at t in depth-first order:
+// it calls visit(t); if that call returns true, WalkType is invoked
+// recursively for each of the component types of t:
+//
+//	*types.Named      the underlying type
+//	*types.Pointer    the element type
+//	*types.Slice      the element type
+//	*types.Array      the element type
+//	*types.Map        the key type, then the element type
+//	*types.Chan       the element type
+//	*types.Struct     the field types, in order
+//	*types.Tuple      the variable types, in order
+//	*types.Signature  the parameter and result tuples (not the receiver)
+//	*types.Interface  the method signatures, in method set order
+//
+// Each named type is visited at most once, which guarantees termination
+// for recursive types. Other types are visited each time they are reached.
+// Empty parameter and result tuples and the missing underlying type of a
+// named type that is not yet defined are not visited; visit is never
+// called with a nil type (unless t is nil).
+//
+func WalkType(t types.Type, visit func(types.Type) bool) {
+	w := walker{visit, make(map[*types.Named]bool)}
+	w.walk(t)
+}
+
+type walker struct {
+	visit func(types.Type) bool
+	seen  map[*types.Named]bool
+}
+
+func (w *walker) walk(t types.Type) {
+	if n, ok := t.(*types.Named); ok {
+		if w.seen[n] {
+			return
+		}
+		w.seen[n] = true
+	}
+
+	if !w.visit(t) {
+		return
+	}
+
+	switch t := t.(type) {
+	case *types.Basic:
+		// no components
+
+	case *types.Named:
+		if u := t.Underlying(); u != nil {
+			w.walk(u)
+		}
+
+	case *types.Pointer:
+		w.walk(t.Elem())
+
+	case *types.Slice:
+		w.walk(t.Elem())
+
+	case *types.Array:
+		w.walk(t.Elem())
+
+	case *types.Map:
+		w.walk(t.Key())
+		w.walk(t.Elem())
+
+	case *types.Chan:
+		w.walk(t.Elem())
+
+	case *types.Struct:
+		for i, n := 0, t.NumFields(); i < n; i++ {
+			w.walk(t.Field(i).Type())
+		}
+
+	case *types.Tuple:
+		for i, n := 0, t.Len(); i < n; i++ {
+			w.walk(t.At(i).Type())
+		}
+
+	case *types.Signature:
+		if t.Params().Len() > 0 {
+			w.walk(t.Params())
+		}
+		if t.Results().Len() > 0 {
+			w.walk(t.Results())
+		}
+
+	case *types.Interface:
+		for i, n := 0, t.NumMethods(); i < n; i++ {
+			w.walk(t.Method(i).Type())
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2014 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package typeutil_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/types"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+func TestWalkType(t *testing.T) {
+	const src = `package p
+type List struct {
+	next *List
+	val  map[string][]byte
+}
+type I interface {
+	M(List) (I, error)
+}
+type F func(chan<- [2]int, ...bool) *F
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := new(types.Config).Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name  string
+		prune string // don't descend into types with this string form
+		want  string // visited types
+	}{
+		{"List", "", "p.List; struct{next *p.List; val map[string][]byte}; *p.List; map[string][]byte; string; []byte; byte"},
+		{"List", "map[string][]byte", "p.List; struct{next *p.List; val map[string][]byte}; *p.List; map[string][]byte"},
+		{"I", "", "p.I; interface{M(p.List) (p.I, error)}; func(p.List) (p.I, error); (p.List); p.List; struct{next *p.List; val map[string][]byte}; *p.List; map[string][]byte; string; []byte; byte; (p.I, error); error; interface{Error() string}; func() string; (string); string"},
+		{"I", "p.List", "p.I; interface{M(p.List) (p.I, error)}; func(p.List) (p.I, error); (p.List); p.List; (p.I, error); error; interface{Error() string}; func() string; (string); string"},
+		{"F", "", "p.F; func(chan<- [2]int, ...bool) *p.F; (chan<- [2]int, []bool); chan<- [2]int; [2]int; int; []bool; bool; (*p.F); *p.F"},
+	} {
+		var visited []string
+		typeutil.WalkType(pkg.Scope().Lookup(test.name).Type(), func(T types.Type) bool {
+			visited = append(visited, T.String())
+			return T.String() != test.prune
+		})
+		if got := strings.Join(visited, "; "); got != test.want {
+			t.Errorf("%s (prune %q):\ngot  %s\nwant %s", test.name, test.prune, got, test.want)
+		}
+	}
+
+	// The underlying type of a named type that is not yet defined is not visited.
+	N := types.NewNamed(types.NewTypeName(token.NoPos, pkg, "N", nil), nil, nil)
+	var visited []types.Type
+	typeutil.WalkType(types.NewPointer(N), func(T types.Type) bool {
+		visited = append(visited, T)
+		return true
+	})
+	if len(visited) != 2 || visited[0] == nil || visited[1] != N {
+		t.Errorf("got visited types %v; want [*p.N p.N]", visited)
+	}
+}
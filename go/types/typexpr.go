@@ -24,11 +24,18 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, path []*TypeNa
 	x.expr = e
 
 	scope, obj := check.scope.LookupParent(e.Name)
+	if obj == nil && e.Name != "_" && check.conf.Universe != nil {
+		scope, obj = check.conf.Universe.LookupParent(e.Name)
+	}
+	if obj == nil && e.Name != "_" && check.conf.Resolve != nil {
+		scope, obj = nil, check.conf.Resolve(e.Name, check.scope)
+	}
 	if obj == nil {
 		if e.Name == "_" {
 			check.errorf(e.Pos(), "cannot use _ as value or type")
 		} else {
 			check.errorf(e.Pos(), "undeclared name: %s", e.Name)
+			check.recordUndeclared(e)
 		}
 		return
 	}
@@ -44,6 +51,10 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, path []*TypeNa
 	// we only have to mark variables, see *Var case below).
 	if pkg := obj.Pkg(); pkg != check.pkg && pkg != nil {
 		delete(check.unusedDotImports[scope], pkg)
+		if pkgName := check.dotImportMap[dotImportKey{scope, obj}]; pkgName != nil {
+			check.recordPkgName(e, pkgName)
+			check.recordDotImport(e, pkgName.imported)
+		}
 	}
 
 	switch obj := obj.(type) {
@@ -121,7 +132,7 @@ func (check *Checker) ident(x *operand, e *ast.Ident, def *Named, path []*TypeNa
 // referring to this type.
 //
 func (check *Checker) typExpr(e ast.Expr, def *Named, path []*TypeName) (T Type) {
-	if trace {
+	if check.tracing() {
 		check.trace(e.Pos(), "%s", e)
 		check.indent++
 		defer func() {
@@ -305,8 +316,12 @@ func (check *Checker) typExprInternal(e ast.Expr, def *Named, path []*TypeName)
 		// Delay this check because it requires fully setup types;
 		// it is safe to continue in any case (was issue 6667).
 		check.delay(func() {
-			if !Comparable(typ.key) {
-				check.errorf(e.Key.Pos(), "invalid map key type %s", typ.key)
+			if ok, because := ComparableReason(typ.key); !ok {
+				if because != typ.key {
+					check.errorf(e.Key.Pos(), "invalid map key type %s (%s is not comparable)", typ.key, because)
+				} else {
+					check.errorf(e.Key.Pos(), "invalid map key type %s", typ.key)
+				}
 			}
 		})
 
@@ -539,7 +554,7 @@ func (check *Checker) interfaceType(iface *Interface, ityp *ast.InterfaceType, d
 		embed, _ := u.(*Interface)
 		if embed == nil {
 			if u != Typ[Invalid] {
-				check.errorf(pos, "%s is not an interface", named)
+				check.errorf(pos, "interface contains embedded non-interface %s", named)
 			}
 			continue
 		}
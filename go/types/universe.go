@@ -14,13 +14,21 @@ import (
 )
 
 var (
-	Universe     *Scope
-	Unsafe       *Package
-	universeIota *Const
-	UniverseByte *Basic // uint8 alias, but has name "byte"
-	UniverseRune *Basic // int32 alias, but has name "rune"
+	Universe      *Scope
+	Unsafe        *Package
+	universeIota  *Const
+	universeError *Named
+	UniverseByte  *Basic // uint8 alias, but has name "byte"
+	UniverseRune  *Basic // int32 alias, but has name "rune"
 )
 
+// Typ contains the predeclared *Basic types indexed by their
+// corresponding BasicKind, including the untyped kinds used for
+// constant expressions and the invalid type.
+//
+// The *Basic type for Typ[Byte] will have the name "uint8".
+// Use Universe.Lookup("byte").Type() to obtain the specific
+// alias basic type named "byte" (and analogous for "rune").
 var Typ = [...]*Basic{
 	Invalid: {Invalid, 0, "invalid type"},
 
@@ -189,6 +197,7 @@ func init() {
 	universeIota = Universe.Lookup("iota").(*Const)
 	UniverseByte = Universe.Lookup("byte").(*TypeName).typ.(*Basic)
 	UniverseRune = Universe.Lookup("rune").(*TypeName).typ.(*Basic)
+	universeError = Universe.Lookup("error").(*TypeName).typ.(*Named)
 }
 
 // Objects with names containing blanks are internal and not entered into